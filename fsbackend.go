@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/spf13/afero"
+)
+
+// newBackendFs builds the afero.Fs the rest of the program operates on,
+// based on the user-selected -backend flag, along with the root path to
+// walk on that Fs. dir is the root the user asked to process; for "os" that
+// root is dir itself, but "basepath" and "webdav" return an afero.Fs that is
+// already rooted at dir, so the walk root for those is "/" - using dir again
+// there would look for dir/dir.
+func newBackendFs(backend, dir string) (afero.Fs, string, error) {
+	switch backend {
+	case "", "os":
+		return afero.NewOsFs(), dir, nil
+	case "basepath":
+		return afero.NewBasePathFs(afero.NewOsFs(), dir), "/", nil
+	case "webdav":
+		u, err := url.Parse(dir)
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid webdav URL %q: %v", dir, err)
+		}
+		fs, err := newWebdavFs(u)
+		if err != nil {
+			return nil, "", err
+		}
+		return fs, "/", nil
+	default:
+		return nil, "", fmt.Errorf("unknown backend %q (want os, basepath, or webdav)", backend)
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewBackendFsBasepathRootIsNotDoubled(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "10.01 Projects"), 0755); err != nil {
+		t.Fatalf("failed to create test directory: %v", err)
+	}
+
+	fs, root, err := newBackendFs("basepath", dir)
+	if err != nil {
+		t.Fatalf("newBackendFs failed: %v", err)
+	}
+
+	folders, err := collectFolders(fs, root, "10")
+	if err != nil {
+		t.Fatalf("collectFolders failed: %v", err)
+	}
+	if len(folders) != 1 || folders[0].restName != "Projects" {
+		t.Fatalf("expected to find 10.01 Projects under the basepath root, got %v", folders)
+	}
+}
+
+func TestNewBackendFsOsRootIsDirItself(t *testing.T) {
+	_, root, err := newBackendFs("os", "/some/dir")
+	if err != nil {
+		t.Fatalf("newBackendFs failed: %v", err)
+	}
+	if root != "/some/dir" {
+		t.Errorf("expected the os backend's root to be the requested dir, got %q", root)
+	}
+}
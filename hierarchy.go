@@ -0,0 +1,585 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+// areaRangeRe matches a Johnny Decimal area directory like "10-19 Finance".
+var areaRangeRe = regexp.MustCompile(`^(\d{2})-(\d{2})$`)
+
+// idFolder is an ID-level directory (e.g. "11.01 Chase") inside a category.
+type idFolder struct {
+	path     string
+	id       int
+	restName string
+}
+
+// categoryFolder is a category-level directory (e.g. "11 Banking") inside an
+// area, holding the ID folders that belong to it.
+type categoryFolder struct {
+	path     string
+	category int
+	restName string
+	ids      []idFolder
+}
+
+// parseAreaRange splits "10-19" into its low and high bounds and validates
+// that it follows the Johnny Decimal convention of a ten-wide range starting
+// on a multiple of ten (10-19, 20-29, ...).
+func parseAreaRange(name string) (low, high int, err error) {
+	m := areaRangeRe.FindStringSubmatch(name)
+	if m == nil {
+		return 0, 0, fmt.Errorf("%q is not an area range like \"10-19\"", name)
+	}
+	low, _ = strconv.Atoi(m[1])
+	high, _ = strconv.Atoi(m[2])
+	if low%10 != 0 || high != low+9 {
+		return 0, 0, fmt.Errorf("area range %q must span a multiple of ten (e.g. 10-19)", name)
+	}
+	return low, high, nil
+}
+
+// validateCategoryInArea checks that a category number belongs to its area's
+// range, per the Johnny Decimal rule that categories 11-19 live in area
+// 10-19 (the area's own round number, e.g. 10, is reserved for the area
+// itself and has no category folder).
+func validateCategoryInArea(category, areaLow, areaHigh int) error {
+	if category <= areaLow || category > areaHigh {
+		return fmt.Errorf("category %02d is not within area range %02d-%02d", category, areaLow, areaHigh)
+	}
+	return nil
+}
+
+// validateIDInCategory checks that an ID number is within the 01-99 (or
+// wider, per cfg.DigitCount) range reserved for IDs inside a category.
+func validateIDInCategory(id, digitCount int) error {
+	maxID := pow10(digitCount) - 1
+	if id < 1 || id > maxID {
+		return fmt.Errorf("id %d is outside the valid 1-%d range for a category", id, maxID)
+	}
+	return nil
+}
+
+func pow10(n int) int {
+	result := 1
+	for i := 0; i < n; i++ {
+		result *= 10
+	}
+	return result
+}
+
+// listCategories collects every category directory directly inside areaPath,
+// each with its ID folders, sorted by category number.
+func listCategories(fs afero.Fs, areaPath string) ([]categoryFolder, error) {
+	entries, err := afero.ReadDir(fs, areaPath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading area %s: %v", areaPath, err)
+	}
+
+	var categories []categoryFolder
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		parts := strings.SplitN(entry.Name(), " ", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		num, err := strconv.Atoi(parts[0])
+		if err != nil {
+			continue
+		}
+
+		cat := categoryFolder{
+			path:     filepath.Join(areaPath, entry.Name()),
+			category: num,
+			restName: parts[1],
+		}
+
+		idEntries, err := afero.ReadDir(fs, cat.path)
+		if err != nil {
+			return nil, fmt.Errorf("error reading category %s: %v", cat.path, err)
+		}
+		for _, idEntry := range idEntries {
+			if !idEntry.IsDir() {
+				continue
+			}
+			idParts := strings.SplitN(idEntry.Name(), " ", 2)
+			if len(idParts) != 2 {
+				continue
+			}
+			numberParts := strings.Split(idParts[0], ".")
+			if len(numberParts) != 2 || numberParts[0] != parts[0] {
+				continue
+			}
+			id, err := strconv.Atoi(numberParts[1])
+			if err != nil {
+				continue
+			}
+			cat.ids = append(cat.ids, idFolder{
+				path:     filepath.Join(cat.path, idEntry.Name()),
+				id:       id,
+				restName: idParts[1],
+			})
+		}
+		sort.Slice(cat.ids, func(i, j int) bool { return cat.ids[i].id < cat.ids[j].id })
+		categories = append(categories, cat)
+	}
+	sort.Slice(categories, func(i, j int) bool { return categories[i].category < categories[j].category })
+	return categories, nil
+}
+
+// findCategory locates the category directory matching categoryNumber
+// directly inside areaPath, and collects the ID folders beneath it.
+func findCategory(fs afero.Fs, areaPath string, categoryNumber int, digitCount int) (categoryFolder, error) {
+	categories, err := listCategories(fs, areaPath)
+	if err != nil {
+		return categoryFolder{}, err
+	}
+	for _, cat := range categories {
+		if cat.category == categoryNumber {
+			return cat, nil
+		}
+	}
+	return categoryFolder{}, fmt.Errorf("category %02d not found in area %s", categoryNumber, areaPath)
+}
+
+// findAreaDir locates the area directory named "<low>-<high> ..." directly
+// inside root.
+func findAreaDir(fs afero.Fs, root string, low, high int) (string, error) {
+	entries, err := afero.ReadDir(fs, root)
+	if err != nil {
+		return "", fmt.Errorf("error reading %s: %v", root, err)
+	}
+	want := fmt.Sprintf("%02d-%02d", low, high)
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		if parts := strings.SplitN(entry.Name(), " ", 2); len(parts) == 2 && parts[0] == want {
+			return filepath.Join(root, entry.Name()), nil
+		}
+	}
+	return "", fmt.Errorf("area %s not found under %s", want, root)
+}
+
+// renameHierarchy retargets the Johnny Decimal hierarchy at whichever level
+// cfg identifies, cascading the renumber down to everything nested beneath
+// that level: renameID for a single ID (cfg.IDFrom set), renameCategory for
+// a whole category (cfg.CategoryFrom set), or renameArea for a whole area
+// (cfg.AreaFrom set alone). Every level validates its whole plan against
+// Johnny Decimal rules - including collisions with whatever already exists
+// at the destination - before touching the filesystem.
+func renameHierarchy(cfg Config) error {
+	if cfg.Fs == nil {
+		return fmt.Errorf("renameHierarchy requires cfg.Fs")
+	}
+
+	switch {
+	case cfg.IDFrom != "":
+		return renameID(cfg)
+	case cfg.CategoryFrom != "":
+		return renameCategory(cfg)
+	case cfg.AreaFrom != "":
+		return renameArea(cfg)
+	default:
+		return fmt.Errorf("renameHierarchy requires one of -area-from, -category-from, or -id-from")
+	}
+}
+
+// renameCategory retargets a category (and, if the area changed, moves it
+// under the new area) and renumbers every ID folder beneath it to match the
+// new category prefix. It validates the whole plan against Johnny Decimal
+// rules - including that categoryTo isn't already taken in the target area -
+// before touching the filesystem, and performs the category move and the ID
+// renames as their own runTransaction calls, chained onto the same journal,
+// so a mid-run failure (or a later -undo) rolls back both instead of leaving
+// the hierarchy half-renumbered.
+func renameCategory(cfg Config) error {
+	fs := cfg.Fs
+
+	fromLow, fromHigh, err := parseAreaRange(cfg.AreaFrom)
+	if err != nil {
+		return err
+	}
+
+	toAreaRange := cfg.AreaTo
+	if toAreaRange == "" {
+		toAreaRange = cfg.AreaFrom
+	}
+	toLow, toHigh, err := parseAreaRange(toAreaRange)
+	if err != nil {
+		return err
+	}
+
+	categoryFrom, err := strconv.Atoi(cfg.CategoryFrom)
+	if err != nil {
+		return fmt.Errorf("invalid category %q: %v", cfg.CategoryFrom, err)
+	}
+	categoryTo := categoryFrom
+	if cfg.CategoryTo != "" {
+		categoryTo, err = strconv.Atoi(cfg.CategoryTo)
+		if err != nil {
+			return fmt.Errorf("invalid category %q: %v", cfg.CategoryTo, err)
+		}
+	}
+
+	if err := validateCategoryInArea(categoryFrom, fromLow, fromHigh); err != nil {
+		return err
+	}
+	if err := validateCategoryInArea(categoryTo, toLow, toHigh); err != nil {
+		return err
+	}
+
+	fromAreaPath, err := findAreaDir(fs, cfg.Dir, fromLow, fromHigh)
+	if err != nil {
+		return err
+	}
+	toAreaPath := fromAreaPath
+	if toLow != fromLow || toHigh != fromHigh {
+		toAreaPath, err = findAreaDir(fs, cfg.Dir, toLow, toHigh)
+		if err != nil {
+			return err
+		}
+	}
+
+	category, err := findCategory(fs, fromAreaPath, categoryFrom, cfg.DigitCount)
+	if err != nil {
+		return err
+	}
+	for _, id := range category.ids {
+		if err := validateIDInCategory(id.id, cfg.DigitCount); err != nil {
+			return fmt.Errorf("%s: %v", id.path, err)
+		}
+	}
+
+	newCategoryName := fmt.Sprintf("%02d %s", categoryTo, category.restName)
+	newCategoryPath := filepath.Join(toAreaPath, newCategoryName)
+
+	if fromAreaPath == toAreaPath && category.path == newCategoryPath {
+		fmt.Printf("Skipping %s (already at %02d-%02d / %02d)\n", category.path, toLow, toHigh, categoryTo)
+		return nil
+	}
+
+	// A category folder is named "<number> <restName>", so the collision
+	// that matters is another category already claiming categoryTo in the
+	// target area, not an exact path match against category.restName.
+	if existing, err := findCategory(fs, toAreaPath, categoryTo, cfg.DigitCount); err == nil && existing.path != category.path {
+		return fmt.Errorf("category %02d already exists at %s", categoryTo, existing.path)
+	}
+
+	idFormat := fmt.Sprintf("%%02d.%%0%dd %%s", cfg.DigitCount)
+	var idPlan []renameStep
+	for _, id := range category.ids {
+		newIDName := fmt.Sprintf(idFormat, categoryTo, id.id, id.restName)
+		oldPath := filepath.Join(newCategoryPath, filepath.Base(id.path))
+		newPath := filepath.Join(newCategoryPath, newIDName)
+		if oldPath == newPath {
+			continue
+		}
+		idPlan = append(idPlan, renameStep{From: oldPath, To: newPath})
+	}
+
+	if cfg.DryRun {
+		fmt.Printf("Would move: %s -> %s\n", category.path, newCategoryPath)
+		for _, id := range category.ids {
+			newIDName := fmt.Sprintf(idFormat, categoryTo, id.id, id.restName)
+			fmt.Printf("Would rename: %s -> %s\n", id.path, filepath.Join(newCategoryPath, newIDName))
+		}
+		return nil
+	}
+
+	journalFs := afero.NewOsFs()
+	journalPath := cfg.JournalPath
+	if journalPath == "" {
+		journalPath = ".jdc-journal.jsonl"
+	}
+
+	fmt.Printf("Moving: %s -> %s\n", category.path, newCategoryPath)
+	categoryPlan := []renameStep{{From: category.path, To: newCategoryPath}}
+	if err := runTransaction(fs, journalFs, categoryPlan, journalPath, true); err != nil {
+		return fmt.Errorf("error moving category %s: %v", category.path, err)
+	}
+
+	// The ID folders moved along with their parent category directory above,
+	// so idPlan's paths (computed against newCategoryPath) are only valid
+	// now; renaming them has to be its own runTransaction call rather than
+	// folded into categoryPlan, since nesting a directory rename with
+	// renames of its own descendants in one plan would corrupt the
+	// two-phase temp-name bookkeeping (the descendants' temp names would be
+	// computed under a path that moves out from under them mid-transaction).
+	// It's chained onto the same journal with fresh=false instead of
+	// started fresh, so a failure here rolls back the category move too,
+	// and a single -undo after success reverses both as one unit.
+	for _, step := range idPlan {
+		fmt.Printf("Renaming: %s -> %s\n", step.From, step.To)
+	}
+	if err := runTransaction(fs, journalFs, idPlan, journalPath, false); err != nil {
+		return fmt.Errorf("error renaming ids under %s: %v", newCategoryPath, err)
+	}
+
+	return nil
+}
+
+// renameArea retargets an entire area to a new range, shifting every
+// category (and the ids beneath it) by the same number of tens the area
+// moved - e.g. area 10-19 -> 30-39 takes category 11 to category 31, since a
+// category's tens digit has to match its area - then renames the area
+// directory itself. It rejects retargeting onto a range another area
+// directory already occupies.
+func renameArea(cfg Config) error {
+	fs := cfg.Fs
+
+	fromLow, fromHigh, err := parseAreaRange(cfg.AreaFrom)
+	if err != nil {
+		return err
+	}
+	if cfg.AreaTo == "" {
+		return fmt.Errorf("renameArea requires -area-to")
+	}
+	toLow, toHigh, err := parseAreaRange(cfg.AreaTo)
+	if err != nil {
+		return err
+	}
+
+	areaPath, err := findAreaDir(fs, cfg.Dir, fromLow, fromHigh)
+	if err != nil {
+		return err
+	}
+
+	if toLow == fromLow && toHigh == fromHigh {
+		fmt.Printf("Skipping %s (already at %02d-%02d)\n", areaPath, toLow, toHigh)
+		return nil
+	}
+
+	if _, err := findAreaDir(fs, cfg.Dir, toLow, toHigh); err == nil {
+		return fmt.Errorf("area %02d-%02d already exists under %s", toLow, toHigh, cfg.Dir)
+	}
+
+	newAreaName := fmt.Sprintf("%02d-%02d", toLow, toHigh)
+	if parts := strings.SplitN(filepath.Base(areaPath), " ", 2); len(parts) == 2 {
+		newAreaName += " " + parts[1]
+	}
+	newAreaPath := filepath.Join(cfg.Dir, newAreaName)
+
+	shift := toLow - fromLow
+	categories, err := listCategories(fs, areaPath)
+	if err != nil {
+		return err
+	}
+	for _, cat := range categories {
+		if err := validateCategoryInArea(cat.category+shift, toLow, toHigh); err != nil {
+			return fmt.Errorf("%s: %v", cat.path, err)
+		}
+		for _, id := range cat.ids {
+			if err := validateIDInCategory(id.id, cfg.DigitCount); err != nil {
+				return fmt.Errorf("%s: %v", id.path, err)
+			}
+		}
+	}
+
+	idFormat := fmt.Sprintf("%%02d.%%0%dd %%s", cfg.DigitCount)
+
+	if cfg.DryRun {
+		fmt.Printf("Would move: %s -> %s\n", areaPath, newAreaPath)
+		for _, cat := range categories {
+			newCatNumber := cat.category + shift
+			newCatPath := filepath.Join(newAreaPath, fmt.Sprintf("%02d %s", newCatNumber, cat.restName))
+			fmt.Printf("Would rename: %s -> %s\n", filepath.Join(newAreaPath, filepath.Base(cat.path)), newCatPath)
+			for _, id := range cat.ids {
+				newIDName := fmt.Sprintf(idFormat, newCatNumber, id.id, id.restName)
+				fmt.Printf("Would rename: %s -> %s\n", filepath.Join(newCatPath, filepath.Base(id.path)), filepath.Join(newCatPath, newIDName))
+			}
+		}
+		return nil
+	}
+
+	journalFs := afero.NewOsFs()
+	journalPath := cfg.JournalPath
+	if journalPath == "" {
+		journalPath = ".jdc-journal.jsonl"
+	}
+
+	// The area moves first, as its own runTransaction call; category and id
+	// renumbers are computed against its new location and chained onto the
+	// same journal (fresh=false), for the same reason renameCategory splits
+	// its category move and id renames: nesting an ancestor's rename with
+	// its descendants' renames in one plan corrupts the two-phase temp-name
+	// bookkeeping. Chaining means a single rollback or -undo reverses the
+	// whole move, not just whichever level failed.
+	fmt.Printf("Moving: %s -> %s\n", areaPath, newAreaPath)
+	if err := runTransaction(fs, journalFs, []renameStep{{From: areaPath, To: newAreaPath}}, journalPath, true); err != nil {
+		return fmt.Errorf("error moving area %s: %v", areaPath, err)
+	}
+
+	var categoryPlan []renameStep
+	newCategoryPaths := make(map[int]string, len(categories))
+	for _, cat := range categories {
+		newCatNumber := cat.category + shift
+		oldPath := filepath.Join(newAreaPath, filepath.Base(cat.path))
+		newPath := filepath.Join(newAreaPath, fmt.Sprintf("%02d %s", newCatNumber, cat.restName))
+		newCategoryPaths[cat.category] = newPath
+		if oldPath != newPath {
+			categoryPlan = append(categoryPlan, renameStep{From: oldPath, To: newPath})
+		}
+	}
+	for _, step := range categoryPlan {
+		fmt.Printf("Renaming: %s -> %s\n", step.From, step.To)
+	}
+	if err := runTransaction(fs, journalFs, categoryPlan, journalPath, false); err != nil {
+		return fmt.Errorf("error renumbering categories under %s: %v", newAreaPath, err)
+	}
+
+	var idPlan []renameStep
+	for _, cat := range categories {
+		newCatNumber := cat.category + shift
+		newCatPath := newCategoryPaths[cat.category]
+		for _, id := range cat.ids {
+			newIDName := fmt.Sprintf(idFormat, newCatNumber, id.id, id.restName)
+			oldPath := filepath.Join(newCatPath, filepath.Base(id.path))
+			newPath := filepath.Join(newCatPath, newIDName)
+			if oldPath != newPath {
+				idPlan = append(idPlan, renameStep{From: oldPath, To: newPath})
+			}
+		}
+	}
+	for _, step := range idPlan {
+		fmt.Printf("Renaming: %s -> %s\n", step.From, step.To)
+	}
+	if err := runTransaction(fs, journalFs, idPlan, journalPath, false); err != nil {
+		return fmt.Errorf("error renumbering ids under %s: %v", newAreaPath, err)
+	}
+
+	return nil
+}
+
+// renameID retargets a single ID folder, optionally moving it into a
+// different category and/or area along the way. It validates the move
+// against Johnny Decimal rules and rejects landing on an ID number already
+// taken in the destination category before touching the filesystem.
+func renameID(cfg Config) error {
+	fs := cfg.Fs
+
+	fromLow, fromHigh, err := parseAreaRange(cfg.AreaFrom)
+	if err != nil {
+		return err
+	}
+	toAreaRange := cfg.AreaTo
+	if toAreaRange == "" {
+		toAreaRange = cfg.AreaFrom
+	}
+	toLow, toHigh, err := parseAreaRange(toAreaRange)
+	if err != nil {
+		return err
+	}
+
+	categoryFrom, err := strconv.Atoi(cfg.CategoryFrom)
+	if err != nil {
+		return fmt.Errorf("invalid category %q: %v", cfg.CategoryFrom, err)
+	}
+	categoryTo := categoryFrom
+	if cfg.CategoryTo != "" {
+		categoryTo, err = strconv.Atoi(cfg.CategoryTo)
+		if err != nil {
+			return fmt.Errorf("invalid category %q: %v", cfg.CategoryTo, err)
+		}
+	}
+
+	idFrom, err := strconv.Atoi(cfg.IDFrom)
+	if err != nil {
+		return fmt.Errorf("invalid id %q: %v", cfg.IDFrom, err)
+	}
+	idTo := idFrom
+	if cfg.IDTo != "" {
+		idTo, err = strconv.Atoi(cfg.IDTo)
+		if err != nil {
+			return fmt.Errorf("invalid id %q: %v", cfg.IDTo, err)
+		}
+	}
+
+	if err := validateCategoryInArea(categoryFrom, fromLow, fromHigh); err != nil {
+		return err
+	}
+	if err := validateCategoryInArea(categoryTo, toLow, toHigh); err != nil {
+		return err
+	}
+	if err := validateIDInCategory(idTo, cfg.DigitCount); err != nil {
+		return err
+	}
+
+	fromAreaPath, err := findAreaDir(fs, cfg.Dir, fromLow, fromHigh)
+	if err != nil {
+		return err
+	}
+	toAreaPath := fromAreaPath
+	if toLow != fromLow || toHigh != fromHigh {
+		toAreaPath, err = findAreaDir(fs, cfg.Dir, toLow, toHigh)
+		if err != nil {
+			return err
+		}
+	}
+
+	fromCategory, err := findCategory(fs, fromAreaPath, categoryFrom, cfg.DigitCount)
+	if err != nil {
+		return err
+	}
+	toCategory := fromCategory
+	if toAreaPath != fromAreaPath || categoryTo != categoryFrom {
+		toCategory, err = findCategory(fs, toAreaPath, categoryTo, cfg.DigitCount)
+		if err != nil {
+			return err
+		}
+	}
+
+	var id idFolder
+	found := false
+	for _, candidate := range fromCategory.ids {
+		if candidate.id == idFrom {
+			id, found = candidate, true
+			break
+		}
+	}
+	if !found {
+		return fmt.Errorf("id %d not found in category %s", idFrom, fromCategory.path)
+	}
+
+	idFormat := fmt.Sprintf("%%02d.%%0%dd %%s", cfg.DigitCount)
+	newName := fmt.Sprintf(idFormat, categoryTo, idTo, id.restName)
+	newPath := filepath.Join(toCategory.path, newName)
+
+	if id.path == newPath {
+		fmt.Printf("Skipping %s (already at %s)\n", id.path, newName)
+		return nil
+	}
+
+	for _, existing := range toCategory.ids {
+		if existing.id == idTo && existing.path != id.path {
+			return fmt.Errorf("id %d already exists at %s", idTo, existing.path)
+		}
+	}
+
+	if cfg.DryRun {
+		fmt.Printf("Would rename: %s -> %s\n", id.path, newPath)
+		return nil
+	}
+
+	journalFs := afero.NewOsFs()
+	journalPath := cfg.JournalPath
+	if journalPath == "" {
+		journalPath = ".jdc-journal.jsonl"
+	}
+
+	fmt.Printf("Renaming: %s -> %s\n", id.path, newPath)
+	if err := runTransaction(fs, journalFs, []renameStep{{From: id.path, To: newPath}}, journalPath, true); err != nil {
+		return fmt.Errorf("error renaming id %s: %v", id.path, err)
+	}
+
+	return nil
+}
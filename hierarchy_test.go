@@ -0,0 +1,256 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestRenameHierarchyRetargetsCategory(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	root := "/johnny-decimal-test"
+
+	createTestDirs(t, fs, root, []string{
+		"10-19 Finance/11 Banking/11.01 Chase",
+		"10-19 Finance/11 Banking/11.02 Wells Fargo",
+		"20-29 People/22 HR",
+	})
+
+	cfg := Config{
+		Dir:          root,
+		DigitCount:   2,
+		Fs:           fs,
+		AreaFrom:     "10-19",
+		AreaTo:       "20-29",
+		CategoryFrom: "11",
+		CategoryTo:   "21",
+	}
+
+	if err := renameHierarchy(cfg); err != nil {
+		t.Fatalf("renameHierarchy failed: %v", err)
+	}
+
+	expected := []string{
+		"21.01 Chase",
+		"21.02 Wells Fargo",
+	}
+	actual := getDirNames(t, fs, root+"/20-29 People/21 Banking")
+	if len(actual) != len(expected) {
+		t.Fatalf("expected %d ids, got %d: %v", len(expected), len(actual), actual)
+	}
+	for i := range expected {
+		if actual[i] != expected[i] {
+			t.Errorf("expected %s, got %s", expected[i], actual[i])
+		}
+	}
+}
+
+func TestRenameHierarchyRejectsCollisionWithExistingCategory(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	root := "/johnny-decimal-test"
+
+	createTestDirs(t, fs, root, []string{
+		"10-19 Finance/11 Banking/11.01 Chase",
+		"20-29 People/21 HR/21.01 Handbook",
+	})
+
+	cfg := Config{
+		Dir:          root,
+		DigitCount:   2,
+		Fs:           fs,
+		AreaFrom:     "10-19",
+		AreaTo:       "20-29",
+		CategoryFrom: "11",
+		CategoryTo:   "21",
+	}
+
+	if err := renameHierarchy(cfg); err == nil {
+		t.Fatal("expected an error moving a category onto one that already exists, got none")
+	}
+
+	// Neither side of the collision should have been touched.
+	actual := getDirNames(t, fs, root+"/20-29 People")
+	expected := []string{"21 HR"}
+	if len(actual) != len(expected) || actual[0] != expected[0] {
+		t.Fatalf("expected %v, got %v", expected, actual)
+	}
+}
+
+func TestRenameHierarchyRejectsCategoryOutsideArea(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	root := "/johnny-decimal-test"
+
+	createTestDirs(t, fs, root, []string{
+		"10-19 Finance/11 Banking/11.01 Chase",
+	})
+
+	cfg := Config{
+		Dir:          root,
+		DigitCount:   2,
+		Fs:           fs,
+		AreaFrom:     "10-19",
+		CategoryFrom: "11",
+		CategoryTo:   "25",
+	}
+
+	err := renameHierarchy(cfg)
+	if err == nil {
+		t.Fatal("expected an error retargeting a category outside its area, got none")
+	}
+}
+
+func TestRenameHierarchyRejectsAreasOwnRoundNumberAsCategory(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	root := "/johnny-decimal-test"
+
+	createTestDirs(t, fs, root, []string{
+		"10-19 Finance/11 Banking/11.01 Chase",
+	})
+
+	// Category 10 is the area's own round number, reserved for the area
+	// itself - not a valid category target within 10-19.
+	cfg := Config{
+		Dir:          root,
+		DigitCount:   2,
+		Fs:           fs,
+		AreaFrom:     "10-19",
+		CategoryFrom: "11",
+		CategoryTo:   "10",
+	}
+
+	if err := renameHierarchy(cfg); err == nil {
+		t.Fatal("expected an error retargeting a category onto the area's own round number, got none")
+	}
+}
+
+func TestRenameHierarchyMovesWholeArea(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	root := "/johnny-decimal-test"
+
+	createTestDirs(t, fs, root, []string{
+		"10-19 Finance/11 Banking/11.01 Chase",
+		"10-19 Finance/11 Banking/11.02 Wells Fargo",
+		"10-19 Finance/12 Taxes/12.01 Receipts",
+	})
+
+	cfg := Config{
+		Dir:        root,
+		DigitCount: 2,
+		Fs:         fs,
+		AreaFrom:   "10-19",
+		AreaTo:     "30-39",
+	}
+
+	if err := renameHierarchy(cfg); err != nil {
+		t.Fatalf("renameHierarchy failed: %v", err)
+	}
+
+	bankingIDs := getDirNames(t, fs, root+"/30-39 Finance/31 Banking")
+	expectedBankingIDs := []string{"31.01 Chase", "31.02 Wells Fargo"}
+	if len(bankingIDs) != len(expectedBankingIDs) {
+		t.Fatalf("expected %v, got %v", expectedBankingIDs, bankingIDs)
+	}
+	for i := range expectedBankingIDs {
+		if bankingIDs[i] != expectedBankingIDs[i] {
+			t.Errorf("expected %s, got %s", expectedBankingIDs[i], bankingIDs[i])
+		}
+	}
+
+	taxIDs := getDirNames(t, fs, root+"/30-39 Finance/32 Taxes")
+	expectedTaxIDs := []string{"32.01 Receipts"}
+	if len(taxIDs) != len(expectedTaxIDs) || taxIDs[0] != expectedTaxIDs[0] {
+		t.Fatalf("expected %v, got %v", expectedTaxIDs, taxIDs)
+	}
+}
+
+func TestRenameHierarchyRejectsAreaMoveOntoExistingArea(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	root := "/johnny-decimal-test"
+
+	createTestDirs(t, fs, root, []string{
+		"10-19 Finance/11 Banking/11.01 Chase",
+		"30-39 Travel",
+	})
+
+	cfg := Config{
+		Dir:        root,
+		DigitCount: 2,
+		Fs:         fs,
+		AreaFrom:   "10-19",
+		AreaTo:     "30-39",
+	}
+
+	if err := renameHierarchy(cfg); err == nil {
+		t.Fatal("expected an error moving an area onto a range another area already occupies, got none")
+	}
+}
+
+func TestRenameHierarchyMovesSingleID(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	root := "/johnny-decimal-test"
+
+	createTestDirs(t, fs, root, []string{
+		"10-19 Finance/11 Banking/11.01 Chase",
+		"10-19 Finance/11 Banking/11.02 Wells Fargo",
+	})
+
+	cfg := Config{
+		Dir:          root,
+		DigitCount:   2,
+		Fs:           fs,
+		AreaFrom:     "10-19",
+		CategoryFrom: "11",
+		IDFrom:       "01",
+		IDTo:         "03",
+	}
+
+	if err := renameHierarchy(cfg); err != nil {
+		t.Fatalf("renameHierarchy failed: %v", err)
+	}
+
+	expected := []string{"11.02 Wells Fargo", "11.03 Chase"}
+	actual := getDirNames(t, fs, root+"/10-19 Finance/11 Banking")
+	if len(actual) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, actual)
+	}
+	for i := range expected {
+		if actual[i] != expected[i] {
+			t.Errorf("expected %s, got %s", expected[i], actual[i])
+		}
+	}
+}
+
+func TestRenameHierarchyRejectsIDCollisionWithExistingID(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	root := "/johnny-decimal-test"
+
+	createTestDirs(t, fs, root, []string{
+		"10-19 Finance/11 Banking/11.01 Chase",
+		"10-19 Finance/11 Banking/11.02 Wells Fargo",
+	})
+
+	cfg := Config{
+		Dir:          root,
+		DigitCount:   2,
+		Fs:           fs,
+		AreaFrom:     "10-19",
+		CategoryFrom: "11",
+		IDFrom:       "01",
+		IDTo:         "02",
+	}
+
+	if err := renameHierarchy(cfg); err == nil {
+		t.Fatal("expected an error moving an id onto one that already exists, got none")
+	}
+
+	actual := getDirNames(t, fs, root+"/10-19 Finance/11 Banking")
+	expected := []string{"11.01 Chase", "11.02 Wells Fargo"}
+	if len(actual) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, actual)
+	}
+	for i := range expected {
+		if actual[i] != expected[i] {
+			t.Errorf("expected %s, got %s", expected[i], actual[i])
+		}
+	}
+}
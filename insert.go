@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+// computeShiftPlan shifts folders matching cfg.OldPrefix by delta (+1 to
+// insert, -1 to delete) to make or close a gap at at, and returns the plan
+// alongside the path of a folder that must be removed first, if any.
+//
+// Inserting opens room at at by shifting the folder already there (if any)
+// and everything above it up by one, so every decimal from at upward is
+// included. Deleting closes the gap left by the folder at at: if one exists
+// there, it is returned as removeFirst rather than renamed, since the
+// occupied slot has to actually go, not be shifted into a collision with the
+// folder below it; only folders strictly above at then shift down to close
+// the gap it leaves behind.
+//
+// It validates the shift against the same digit-count ceiling used
+// elsewhere before returning a plan.
+func computeShiftPlan(fs afero.Fs, cfg Config, at int, delta int) (plan []renameStep, removeFirst string, err error) {
+	folders, err := collectFolders(fs, cfg.Dir, cfg.OldPrefix)
+	if err != nil {
+		return nil, "", err
+	}
+
+	maxNumber := int(math.Pow10(cfg.DigitCount)) - 1
+	format := fmt.Sprintf("%%s.%%0%dd %%s", cfg.DigitCount)
+	threshold := at
+	if delta < 0 {
+		threshold = at + 1
+		for _, f := range folders {
+			if f.decimal == at {
+				removeFirst = f.path
+				break
+			}
+		}
+	}
+
+	for _, f := range folders {
+		if f.decimal < threshold {
+			continue
+		}
+
+		newDecimal := f.decimal + delta
+		if newDecimal < 1 {
+			return nil, "", fmt.Errorf("deleting at %d would push %s below 1", at, f.path)
+		}
+		if newDecimal > maxNumber {
+			return nil, "", fmt.Errorf("inserting at %d would push %s past xx.%s", at, f.path, strings.Repeat("9", cfg.DigitCount))
+		}
+
+		newName := fmt.Sprintf(format, cfg.OldPrefix, newDecimal, f.restName)
+		newPath := filepath.Join(filepath.Dir(f.path), newName)
+		if f.path == newPath {
+			continue
+		}
+		plan = append(plan, renameStep{From: f.path, To: newPath})
+	}
+
+	return plan, removeFirst, nil
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func applyShift(t *testing.T, fs afero.Fs, dir string, cfg Config, at, delta int) error {
+	plan, removeFirst, err := computeShiftPlan(fs, cfg, at, delta)
+	if err != nil {
+		return err
+	}
+	if removeFirst != "" {
+		if err := fs.RemoveAll(removeFirst); err != nil {
+			return err
+		}
+	}
+	return runTransaction(fs, fs, plan, dir+"/.jdc-journal.jsonl", true)
+}
+
+func TestInsertIntoSparseCategoryPreservesGapsAboveInsertionPoint(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	tempDir := "/johnny-decimal-test"
+
+	createTestDirs(t, fs, tempDir, []string{
+		"20.01 Some folder",
+		"20.02 Another folder",
+		"20.10 Yet another folder",
+	})
+
+	cfg := Config{OldPrefix: "20", Dir: tempDir, DigitCount: 2, Fs: fs}
+
+	if err := applyShift(t, fs, tempDir, cfg, 2, 1); err != nil {
+		t.Fatalf("insert failed: %v", err)
+	}
+
+	expected := []string{
+		"20.01 Some folder",
+		"20.03 Another folder",
+		"20.11 Yet another folder",
+	}
+	actual := getDirNames(t, fs, tempDir)
+	if len(actual) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, actual)
+	}
+	for i := range expected {
+		if actual[i] != expected[i] {
+			t.Errorf("expected %s, got %s", expected[i], actual[i])
+		}
+	}
+}
+
+func TestInsertThatWouldOverflowIsRejected(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	tempDir := "/johnny-decimal-test"
+
+	createTestDirs(t, fs, tempDir, []string{
+		"20.98 Near the end",
+		"20.99 Last",
+	})
+
+	cfg := Config{OldPrefix: "20", Dir: tempDir, DigitCount: 2, Fs: fs}
+
+	_, _, err := computeShiftPlan(fs, cfg, 98, 1)
+	if err == nil {
+		t.Fatal("expected an error when inserting would overflow xx.99, but got none")
+	}
+}
+
+func TestDeleteSkipsAlreadyMissingNumbers(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	tempDir := "/johnny-decimal-test"
+
+	createTestDirs(t, fs, tempDir, []string{
+		"20.01 Some folder",
+		"20.03 Another folder",
+		"20.10 Yet another folder",
+	})
+
+	cfg := Config{OldPrefix: "20", Dir: tempDir, DigitCount: 2, Fs: fs}
+
+	// 20.02 doesn't exist; deleting at 2 should still close the gap by
+	// shifting everything from 2 upward down by one.
+	if err := applyShift(t, fs, tempDir, cfg, 2, -1); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+
+	expected := []string{
+		"20.01 Some folder",
+		"20.02 Another folder",
+		"20.09 Yet another folder",
+	}
+	actual := getDirNames(t, fs, tempDir)
+	if len(actual) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, actual)
+	}
+	for i := range expected {
+		if actual[i] != expected[i] {
+			t.Errorf("expected %s, got %s", expected[i], actual[i])
+		}
+	}
+}
+
+func TestDeleteOccupiedSlotRemovesItThenShiftsAboveDown(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	tempDir := "/johnny-decimal-test"
+
+	createTestDirs(t, fs, tempDir, []string{
+		"20.01 First",
+		"20.02 Second",
+		"20.03 Third",
+		"20.10 Tenth",
+	})
+
+	cfg := Config{OldPrefix: "20", Dir: tempDir, DigitCount: 2, Fs: fs}
+
+	// 20.03 exists; deleting at 3 must remove it outright, not rename it
+	// into a collision with 20.02, and only shift what's above it down.
+	if err := applyShift(t, fs, tempDir, cfg, 3, -1); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+
+	expected := []string{
+		"20.01 First",
+		"20.02 Second",
+		"20.09 Tenth",
+	}
+	actual := getDirNames(t, fs, tempDir)
+	if len(actual) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, actual)
+	}
+	for i := range expected {
+		if actual[i] != expected[i] {
+			t.Errorf("expected %s, got %s", expected[i], actual[i])
+		}
+	}
+}
+
+func TestDeleteOccupiedFirstSlotDoesNotErrorBelowOne(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	tempDir := "/johnny-decimal-test"
+
+	createTestDirs(t, fs, tempDir, []string{
+		"20.01 First",
+		"20.02 Second",
+	})
+
+	cfg := Config{OldPrefix: "20", Dir: tempDir, DigitCount: 2, Fs: fs}
+
+	// Deleting the very first slot used to always fail, since the old code
+	// tried to shift 20.01 itself down to 20.00.
+	if err := applyShift(t, fs, tempDir, cfg, 1, -1); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+
+	expected := []string{"20.01 Second"}
+	actual := getDirNames(t, fs, tempDir)
+	if len(actual) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, actual)
+	}
+	for i := range expected {
+		if actual[i] != expected[i] {
+			t.Errorf("expected %s, got %s", expected[i], actual[i])
+		}
+	}
+}
@@ -9,6 +9,8 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+
+	"github.com/spf13/afero"
 )
 
 type folder struct {
@@ -18,66 +20,51 @@ type folder struct {
 }
 
 type Config struct {
-	OldPrefix   string
-	NewPrefix   string
-	StartFrom   int
+	OldPrefix  string
+	NewPrefix  string
+	StartFrom  int
 	Dir        string
 	DryRun     bool
 	DigitCount int // Number of digits after the decimal point
-}
+	Fs         afero.Fs
 
-// renameDirectories performs the actual renaming logic
-func renameDirectories(cfg Config) error {
-	// Validate inputs
-	if cfg.DigitCount < 1 {
-		return fmt.Errorf("digit count must be at least 1")
-	}
+	// Full hierarchy support: retarget an area, a category within it, or a
+	// single ID within a category, cascading the renumber down to whatever
+	// is nested beneath the level that changed. Which fields are set picks
+	// the level: IDFrom dispatches to an ID move, else CategoryFrom to a
+	// category move, else AreaFrom alone to an area move. See
+	// renameHierarchy.
+	AreaFrom     string // e.g. "10-19"
+	AreaTo       string // e.g. "20-29"
+	CategoryFrom string // e.g. "11"
+	CategoryTo   string // e.g. "21"
+	IDFrom       string // e.g. "01"
+	IDTo         string // e.g. "02"
 
-	// If we're renumbering, validate that we won't exceed the maximum
-	if cfg.StartFrom > 0 {
-		// Count how many folders we need to renumber
-		folderCount := 0
-		err := filepath.Walk(cfg.Dir, func(path string, info os.FileInfo, err error) error {
-			if err != nil || !info.IsDir() || path == cfg.Dir {
-				return nil
-			}
-			baseName := filepath.Base(path)
-			parts := strings.SplitN(baseName, " ", 2)
-			if len(parts) != 2 {
-				return nil
-			}
-			numberParts := strings.Split(parts[0], ".")
-			if len(numberParts) != 2 || numberParts[0] != cfg.OldPrefix {
-				return nil
-			}
-			folderCount++
-			return nil
-		})
-		if err != nil {
-			return fmt.Errorf("error counting folders: %v", err)
-		}
+	// JournalPath is where the transactional rename records its progress.
+	// It always lives on the local filesystem - regardless of which backend
+	// Fs renames on - so it survives independently of a remote backend's
+	// availability. Defaults to "./.jdc-journal.jsonl" when empty.
+	JournalPath string
 
-		// Check if the last number would exceed the maximum
-		lastNumber := cfg.StartFrom + folderCount - 1
-		maxNumber := int(math.Pow10(cfg.DigitCount)) - 1
-		if lastNumber > maxNumber {
-			return fmt.Errorf("renumbering would exceed xx.%s (last number would be %d)", strings.Repeat("9", cfg.DigitCount), lastNumber)
-		}
-	}
+	// Name-normalization pipeline for the restName portion. See MakeName.
+	Normalize     string // "title", "slug", "kebab", or "none"/""
+	NameSeparator string // separator used by "title" and "slug", default "-"
+	RemoveAccents bool
+	MaxNameLen    int
+	NormalizeOnly bool
+}
 
-	// Collect all matching folders first
+// collectFolders walks dir and returns every immediate-and-deeper directory
+// named "<prefix>.<decimal> restName", sorted by decimal.
+func collectFolders(fs afero.Fs, dir, prefix string) ([]folder, error) {
 	var folders []folder
-	err := filepath.Walk(cfg.Dir, func(path string, info os.FileInfo, err error) error {
+	err := afero.Walk(fs, dir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
 
-		if !info.IsDir() {
-			return nil
-		}
-
-		// Skip the root directory
-		if path == cfg.Dir {
+		if !info.IsDir() || path == dir {
 			return nil
 		}
 
@@ -85,84 +72,117 @@ func renameDirectories(cfg Config) error {
 		parts := strings.SplitN(baseName, " ", 2)
 		if len(parts) == 2 {
 			numberParts := strings.Split(parts[0], ".")
-			if len(numberParts) == 2 && numberParts[0] == cfg.OldPrefix {
+			if len(numberParts) == 2 && numberParts[0] == prefix {
 				decimal, err := strconv.Atoi(numberParts[1])
-				if err != nil {
-					return nil
+				if err == nil {
+					folders = append(folders, folder{path: path, decimal: decimal, restName: parts[1]})
 				}
-
-				folders = append(folders, folder{path: path, decimal: decimal, restName: parts[1]})
 			}
 		}
 
 		return nil
 	})
-
 	if err != nil {
-		return fmt.Errorf("error walking directory: %v", err)
+		return nil, fmt.Errorf("error walking directory: %v", err)
 	}
 
-	// Sort folders by their current decimal number
 	sort.Slice(folders, func(i, j int) bool {
 		return folders[i].decimal < folders[j].decimal
 	})
+	return folders, nil
+}
+
+// computeRenamePlan discovers the folders matching cfg.OldPrefix and
+// computes their final destinations, without touching the filesystem.
+// Folders that would keep their existing name are reported and omitted
+// from the plan.
+func computeRenamePlan(fs afero.Fs, cfg Config) ([]renameStep, error) {
+	folders, err := collectFolders(fs, cfg.Dir, cfg.OldPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	// If we're renumbering, validate that we won't exceed the maximum
+	if cfg.StartFrom > 0 {
+		lastNumber := cfg.StartFrom + len(folders) - 1
+		maxNumber := int(math.Pow10(cfg.DigitCount)) - 1
+		if lastNumber > maxNumber {
+			return nil, fmt.Errorf("renumbering would exceed xx.%s (last number would be %d)", strings.Repeat("9", cfg.DigitCount), lastNumber)
+		}
+	}
 
 	// Use oldPrefix as newPrefix if not specified but startFrom is
 	if cfg.StartFrom > 0 && cfg.NewPrefix == "" {
 		cfg.NewPrefix = cfg.OldPrefix
 	}
 
-	// Renumber and rename the folders
+	// Renumber and compute the plan
+	var plan []renameStep
 	newDecimal := cfg.StartFrom
-	if newDecimal == 0 {
-		// If no start number provided, keep original decimal numbers
-		for _, f := range folders {
-			format := fmt.Sprintf("%%s.%%0%dd %%s", cfg.DigitCount)
-			newName := fmt.Sprintf(format, cfg.NewPrefix, f.decimal, f.restName)
-			newPath := filepath.Join(filepath.Dir(f.path), newName)
-
-			// Skip if the source and target paths are identical
-			if f.path == newPath {
-				fmt.Printf("Skipping %s (already has correct name)\n", f.path)
-				continue
-			}
+	for _, f := range folders {
+		decimal := f.decimal
+		if newDecimal != 0 {
+			decimal = newDecimal
+			newDecimal++
+		}
 
-			if cfg.DryRun {
-				fmt.Printf("Would rename: %s -> %s\n", f.path, newPath)
-			} else {
-				fmt.Printf("Renaming: %s -> %s\n", f.path, newPath)
-				if err := os.Rename(f.path, newPath); err != nil {
-					fmt.Printf("Error renaming %s: %v\n", f.path, err)
-				}
-			}
+		restName := f.restName
+		if cfg.Normalize != "" && cfg.Normalize != "none" {
+			restName = MakeName(restName, cfg.Normalize, cfg.NameSeparator, cfg.RemoveAccents, cfg.MaxNameLen)
 		}
-	} else {
-		// Renumber starting from the specified number
-		for _, f := range folders {
-			format := fmt.Sprintf("%%s.%%0%dd %%s", cfg.DigitCount)
-			newName := fmt.Sprintf(format, cfg.NewPrefix, newDecimal, f.restName)
-			newPath := filepath.Join(filepath.Dir(f.path), newName)
-
-			// Skip if the source and target paths are identical
-			if f.path == newPath {
-				fmt.Printf("Skipping %s (already has correct name)\n", f.path)
-				newDecimal++
-				continue
-			}
 
-			if cfg.DryRun {
-				fmt.Printf("Would rename: %s -> %s\n", f.path, newPath)
-			} else {
-				fmt.Printf("Renaming: %s -> %s\n", f.path, newPath)
-				if err := os.Rename(f.path, newPath); err != nil {
-					fmt.Printf("Error renaming %s: %v\n", f.path, err)
-				}
-			}
-			newDecimal++
+		format := fmt.Sprintf("%%s.%%0%dd %%s", cfg.DigitCount)
+		newName := fmt.Sprintf(format, cfg.NewPrefix, decimal, restName)
+		newPath := filepath.Join(filepath.Dir(f.path), newName)
+
+		// Skip if the source and target paths are identical
+		if f.path == newPath {
+			fmt.Printf("Skipping %s (already has correct name)\n", f.path)
+			continue
 		}
+
+		plan = append(plan, renameStep{From: f.path, To: newPath})
 	}
 
-	return nil
+	return plan, nil
+}
+
+// renameDirectories validates cfg, computes the full rename plan, and -
+// unless this is a dry run - executes it as a two-phase transaction so a
+// mid-run failure or a colliding pair of targets (e.g. swapping 10.02 and
+// 10.03) never leaves the tree half-renamed.
+func renameDirectories(cfg Config) error {
+	if cfg.DigitCount < 1 {
+		return fmt.Errorf("digit count must be at least 1")
+	}
+
+	fs := cfg.Fs
+	if fs == nil {
+		fs = afero.NewOsFs()
+	}
+
+	plan, err := computeRenamePlan(fs, cfg)
+	if err != nil {
+		return err
+	}
+
+	if cfg.DryRun {
+		for _, step := range plan {
+			fmt.Printf("Would rename: %s -> %s\n", step.From, step.To)
+		}
+		return nil
+	}
+
+	for _, step := range plan {
+		fmt.Printf("Renaming: %s -> %s\n", step.From, step.To)
+	}
+
+	journalPath := cfg.JournalPath
+	if journalPath == "" {
+		journalPath = ".jdc-journal.jsonl"
+	}
+
+	return runTransaction(fs, afero.NewOsFs(), plan, journalPath, true)
 }
 
 func main() {
@@ -172,6 +192,25 @@ func main() {
 	var dir string
 	var dryRun bool
 	var digitCount int
+	var backend string
+	var areaFrom string
+	var areaTo string
+	var categoryFrom string
+	var categoryTo string
+	var idFrom string
+	var idTo string
+	var journalPath string
+	var resumeJournal string
+	var undoJournal string
+	var watch bool
+	var watchIgnore string
+	var normalize string
+	var nameSeparator string
+	var removeAccents bool
+	var maxNameLen int
+	var normalizeOnly bool
+	var insertAt int
+	var deleteAt int
 
 	flag.StringVar(&oldPrefix, "from", "", "Original prefix number (e.g., '10')")
 	flag.StringVar(&newPrefix, "to", "", "New prefix number (e.g., '20')")
@@ -179,8 +218,187 @@ func main() {
 	flag.StringVar(&dir, "dir", ".", "Directory to process")
 	flag.BoolVar(&dryRun, "dry-run", false, "Preview changes without making them")
 	flag.IntVar(&digitCount, "digits", 2, "Number of digits after the decimal point (default: 2)")
+	flag.StringVar(&backend, "backend", "os", "Filesystem backend to use: os, basepath, or webdav")
+	flag.StringVar(&areaFrom, "area-from", "", "Area range to retarget a category within (e.g., '10-19')")
+	flag.StringVar(&areaTo, "area-to", "", "Area range to move the category into, if it changed (e.g., '20-29')")
+	flag.StringVar(&categoryFrom, "category-from", "", "Category number to retarget (e.g., '11')")
+	flag.StringVar(&categoryTo, "category-to", "", "New category number (e.g., '21')")
+	flag.StringVar(&idFrom, "id-from", "", "ID number to retarget within a category (e.g., '01')")
+	flag.StringVar(&idTo, "id-to", "", "New ID number (e.g., '02')")
+	flag.StringVar(&journalPath, "journal", "", "Path to the rollback journal, always on the local filesystem (default: ./.jdc-journal.jsonl)")
+	flag.StringVar(&resumeJournal, "resume", "", "Resume a crashed run from the given journal file")
+	flag.StringVar(&undoJournal, "undo", "", "Reverse a previously completed run recorded in the given journal file")
+	flag.BoolVar(&watch, "watch", false, "Watch dir recursively and auto-number newly created folders")
+	flag.StringVar(&watchIgnore, "watch-ignore", ".*,~*", "Comma-separated glob patterns to ignore in watch mode")
+	flag.StringVar(&normalize, "normalize", "none", "Normalize folder names: title, slug, kebab, or none")
+	flag.StringVar(&nameSeparator, "name-separator", "-", "Separator used by -normalize=title and -normalize=slug")
+	flag.BoolVar(&removeAccents, "remove-accents", false, "Strip accents/combining marks from folder names")
+	flag.IntVar(&maxNameLen, "max-name-len", 0, "Maximum length of the name portion of a folder, in runes (0 = unlimited)")
+	flag.BoolVar(&normalizeOnly, "normalize-only", false, "Only rewrite restName via -normalize, leaving numeric prefixes untouched")
+	flag.IntVar(&insertAt, "insert", 0, "Make room at this decimal, shifting it and everything above it up by one")
+	flag.IntVar(&deleteAt, "delete", 0, "Remove this decimal, shifting everything above it down by one")
 	flag.Parse()
 
+	if insertAt > 0 || deleteAt > 0 {
+		if oldPrefix == "" {
+			fmt.Println("Please provide the -from prefix")
+			flag.Usage()
+			os.Exit(1)
+		}
+		fs, root, err := newBackendFs(backend, dir)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		config := Config{OldPrefix: oldPrefix, Dir: root, DigitCount: digitCount, Fs: fs}
+
+		at, delta := insertAt, 1
+		if deleteAt > 0 {
+			at, delta = deleteAt, -1
+		}
+
+		plan, removeFirst, err := computeShiftPlan(fs, config, at, delta)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		// removeFirst is moved aside to a trash path as part of the same
+		// plan, rather than deleted outright up front, so that a failure in
+		// the shift half of the transaction rolls back the removal too
+		// instead of leaving the occupied slot's contents already gone. Only
+		// once the whole transaction has committed is the trash path
+		// actually purged, which is the one irreversible step -delete still
+		// has: -undo after that point can restore the shifted numbering but
+		// not the deleted folder's contents.
+		var trashPath string
+		if removeFirst != "" {
+			trashPath = removeFirst + ".jdc-trash"
+			plan = append([]renameStep{{From: removeFirst, To: trashPath}}, plan...)
+		}
+
+		if dryRun {
+			for _, step := range plan {
+				fmt.Printf("Would rename: %s -> %s\n", step.From, step.To)
+			}
+			if trashPath != "" {
+				fmt.Printf("Would remove: %s\n", trashPath)
+			}
+			return
+		}
+		for _, step := range plan {
+			fmt.Printf("Renaming: %s -> %s\n", step.From, step.To)
+		}
+		journal := journalPath
+		if journal == "" {
+			journal = ".jdc-journal.jsonl"
+		}
+		if err := runTransaction(fs, afero.NewOsFs(), plan, journal, true); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		if trashPath != "" {
+			fmt.Printf("Removing: %s\n", trashPath)
+			if err := fs.RemoveAll(trashPath); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		return
+	}
+
+	if normalizeOnly {
+		fs, root, err := newBackendFs(backend, dir)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		config := Config{
+			Dir:           root,
+			DryRun:        dryRun,
+			DigitCount:    digitCount,
+			Fs:            fs,
+			Normalize:     normalize,
+			NameSeparator: nameSeparator,
+			RemoveAccents: removeAccents,
+			MaxNameLen:    maxNameLen,
+		}
+		plan, err := computeNormalizeOnlyPlan(fs, config)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		if dryRun {
+			for _, step := range plan {
+				fmt.Printf("Would rename: %s -> %s\n", step.From, step.To)
+			}
+			return
+		}
+		for _, step := range plan {
+			fmt.Printf("Renaming: %s -> %s\n", step.From, step.To)
+		}
+		journal := journalPath
+		if journal == "" {
+			journal = ".jdc-journal.jsonl"
+		}
+		if err := runTransaction(fs, afero.NewOsFs(), plan, journal, true); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if watch {
+		if err := watchMode(watchConfig{Dir: dir, DigitCount: digitCount, IgnoreGlobs: strings.Split(watchIgnore, ",")}); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if resumeJournal != "" || undoJournal != "" {
+		fs, _, err := newBackendFs(backend, dir)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		if resumeJournal != "" {
+			err = resumeTransaction(fs, afero.NewOsFs(), resumeJournal)
+		} else {
+			err = undoTransaction(fs, afero.NewOsFs(), undoJournal)
+		}
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if areaFrom != "" {
+		fs, root, err := newBackendFs(backend, dir)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		config := Config{
+			Dir:          root,
+			DryRun:       dryRun,
+			DigitCount:   digitCount,
+			Fs:           fs,
+			AreaFrom:     areaFrom,
+			AreaTo:       areaTo,
+			CategoryFrom: categoryFrom,
+			CategoryTo:   categoryTo,
+			IDFrom:       idFrom,
+			IDTo:         idTo,
+		}
+		if err := renameHierarchy(config); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	if oldPrefix == "" {
 		fmt.Println("Please provide the -from prefix")
 		flag.Usage()
@@ -194,13 +412,25 @@ func main() {
 		os.Exit(1)
 	}
 
+	fs, root, err := newBackendFs(backend, dir)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
 	config := Config{
-		OldPrefix:   oldPrefix,
-		NewPrefix:   newPrefix,
-		StartFrom:   startFrom,
-		Dir:        dir,
-		DryRun:     dryRun,
-		DigitCount: digitCount,
+		OldPrefix:     oldPrefix,
+		NewPrefix:     newPrefix,
+		StartFrom:     startFrom,
+		Dir:           root,
+		DryRun:        dryRun,
+		DigitCount:    digitCount,
+		Fs:            fs,
+		JournalPath:   journalPath,
+		Normalize:     normalize,
+		NameSeparator: nameSeparator,
+		RemoveAccents: removeAccents,
+		MaxNameLen:    maxNameLen,
 	}
 
 	if err := renameDirectories(config); err != nil {
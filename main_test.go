@@ -1,24 +1,23 @@
 package main
 
 import (
-	"os"
-	"path/filepath"
 	"sort"
 	"strings"
 	"testing"
+
+	"github.com/spf13/afero"
 )
 
-func createTestDirs(t *testing.T, baseDir string, names []string) {
+func createTestDirs(t *testing.T, fs afero.Fs, baseDir string, names []string) {
 	for _, name := range names {
-		err := os.Mkdir(filepath.Join(baseDir, name), 0755)
-		if err != nil {
+		if err := fs.MkdirAll(baseDir+"/"+name, 0755); err != nil {
 			t.Fatalf("Failed to create test directory %s: %v", name, err)
 		}
 	}
 }
 
-func getDirNames(t *testing.T, dir string) []string {
-	entries, err := os.ReadDir(dir)
+func getDirNames(t *testing.T, fs afero.Fs, dir string) []string {
+	entries, err := afero.ReadDir(fs, dir)
 	if err != nil {
 		t.Fatalf("Failed to read directory %s: %v", dir, err)
 	}
@@ -34,12 +33,8 @@ func getDirNames(t *testing.T, dir string) []string {
 }
 
 func TestRenameWithNewPrefix(t *testing.T) {
-	// Create temporary directory
-	tempDir, err := os.MkdirTemp("", "johnny-decimal-test-*")
-	if err != nil {
-		t.Fatalf("Failed to create temp dir: %v", err)
-	}
-	defer os.RemoveAll(tempDir)
+	fs := afero.NewMemMapFs()
+	tempDir := "/johnny-decimal-test"
 
 	// Create test directories
 	testDirs := []string{
@@ -47,17 +42,18 @@ func TestRenameWithNewPrefix(t *testing.T) {
 		"10.02 Documents",
 		"10.03 Archive",
 	}
-	createTestDirs(t, tempDir, testDirs)
+	createTestDirs(t, fs, tempDir, testDirs)
 
 	// Run the program
 	cfg := Config{
-		OldPrefix:   "10",
-		NewPrefix:   "20",
+		OldPrefix:  "10",
+		NewPrefix:  "20",
 		Dir:        tempDir,
 		DigitCount: 2,
+		Fs:         fs,
 	}
 
-	err = renameDirectories(cfg)
+	err := renameDirectories(cfg)
 	if err != nil {
 		t.Fatalf("Failed to rename directories: %v", err)
 	}
@@ -68,7 +64,7 @@ func TestRenameWithNewPrefix(t *testing.T) {
 		"20.02 Documents",
 		"20.03 Archive",
 	}
-	actual := getDirNames(t, tempDir)
+	actual := getDirNames(t, fs, tempDir)
 
 	if len(actual) != len(expected) {
 		t.Errorf("Expected %d directories, got %d", len(expected), len(actual))
@@ -86,12 +82,8 @@ func TestRenameWithNewPrefix(t *testing.T) {
 }
 
 func TestRenumberWithSamePrefix(t *testing.T) {
-	// Create temporary directory
-	tempDir, err := os.MkdirTemp("", "johnny-decimal-test-*")
-	if err != nil {
-		t.Fatalf("Failed to create temp dir: %v", err)
-	}
-	defer os.RemoveAll(tempDir)
+	fs := afero.NewMemMapFs()
+	tempDir := "/johnny-decimal-test"
 
 	// Create test directories
 	testDirs := []string{
@@ -99,17 +91,18 @@ func TestRenumberWithSamePrefix(t *testing.T) {
 		"10.02 Documents",
 		"10.03 Archive",
 	}
-	createTestDirs(t, tempDir, testDirs)
+	createTestDirs(t, fs, tempDir, testDirs)
 
 	// Run the program with renumbering
 	cfg := Config{
-		OldPrefix:   "10",
-		StartFrom:   5,
+		OldPrefix:  "10",
+		StartFrom:  5,
 		Dir:        tempDir,
 		DigitCount: 2,
+		Fs:         fs,
 	}
 
-	err = renameDirectories(cfg)
+	err := renameDirectories(cfg)
 	if err != nil {
 		t.Fatalf("Failed to rename directories: %v", err)
 	}
@@ -120,7 +113,7 @@ func TestRenumberWithSamePrefix(t *testing.T) {
 		"10.06 Documents",
 		"10.07 Archive",
 	}
-	actual := getDirNames(t, tempDir)
+	actual := getDirNames(t, fs, tempDir)
 
 	if len(actual) != len(expected) {
 		t.Errorf("Expected %d directories, got %d", len(expected), len(actual))
@@ -138,12 +131,8 @@ func TestRenumberWithSamePrefix(t *testing.T) {
 }
 
 func TestRenumberWithGaps(t *testing.T) {
-	// Create temporary directory
-	tempDir, err := os.MkdirTemp("", "johnny-decimal-test-*")
-	if err != nil {
-		t.Fatalf("Failed to create temp dir: %v", err)
-	}
-	defer os.RemoveAll(tempDir)
+	fs := afero.NewMemMapFs()
+	tempDir := "/johnny-decimal-test"
 
 	// Create test directories with gaps in numbers
 	testDirs := []string{
@@ -151,17 +140,18 @@ func TestRenumberWithGaps(t *testing.T) {
 		"20.02 Another folder",
 		"20.10 Yet another folder",
 	}
-	createTestDirs(t, tempDir, testDirs)
+	createTestDirs(t, fs, tempDir, testDirs)
 
 	// Run the program with renumbering
 	cfg := Config{
-		OldPrefix:   "20",
-		StartFrom:   14,
+		OldPrefix:  "20",
+		StartFrom:  14,
 		Dir:        tempDir,
 		DigitCount: 2,
+		Fs:         fs,
 	}
 
-	err = renameDirectories(cfg)
+	err := renameDirectories(cfg)
 	if err != nil {
 		t.Fatalf("Failed to rename directories: %v", err)
 	}
@@ -172,7 +162,7 @@ func TestRenumberWithGaps(t *testing.T) {
 		"20.15 Another folder",
 		"20.16 Yet another folder",
 	}
-	actual := getDirNames(t, tempDir)
+	actual := getDirNames(t, fs, tempDir)
 
 	if len(actual) != len(expected) {
 		t.Errorf("Expected %d directories, got %d", len(expected), len(actual))
@@ -190,12 +180,8 @@ func TestRenumberWithGaps(t *testing.T) {
 }
 
 func TestSkipIdenticalPaths(t *testing.T) {
-	// Create temporary directory
-	tempDir, err := os.MkdirTemp("", "johnny-decimal-test-*")
-	if err != nil {
-		t.Fatalf("Failed to create temp dir: %v", err)
-	}
-	defer os.RemoveAll(tempDir)
+	fs := afero.NewMemMapFs()
+	tempDir := "/johnny-decimal-test"
 
 	// Create test directories
 	testDirs := []string{
@@ -203,17 +189,18 @@ func TestSkipIdenticalPaths(t *testing.T) {
 		"20.15 Another folder",
 		"20.16 Yet another folder",
 	}
-	createTestDirs(t, tempDir, testDirs)
+	createTestDirs(t, fs, tempDir, testDirs)
 
 	// Try to renumber with the same numbers
 	cfg := Config{
-		OldPrefix:   "20",
-		StartFrom:   14,
+		OldPrefix:  "20",
+		StartFrom:  14,
 		Dir:        tempDir,
 		DigitCount: 2,
+		Fs:         fs,
 	}
 
-	err = renameDirectories(cfg)
+	err := renameDirectories(cfg)
 	if err != nil {
 		t.Fatalf("Failed to rename directories: %v", err)
 	}
@@ -224,7 +211,7 @@ func TestSkipIdenticalPaths(t *testing.T) {
 		"20.15 Another folder",
 		"20.16 Yet another folder",
 	}
-	actual := getDirNames(t, tempDir)
+	actual := getDirNames(t, fs, tempDir)
 
 	if len(actual) != len(expected) {
 		t.Errorf("Expected %d directories, got %d", len(expected), len(actual))
@@ -242,12 +229,8 @@ func TestSkipIdenticalPaths(t *testing.T) {
 }
 
 func TestPreventExceeding99(t *testing.T) {
-	// Create temporary directory
-	tempDir, err := os.MkdirTemp("", "johnny-decimal-test-*")
-	if err != nil {
-		t.Fatalf("Failed to create temp dir: %v", err)
-	}
-	defer os.RemoveAll(tempDir)
+	fs := afero.NewMemMapFs()
+	tempDir := "/johnny-decimal-test"
 
 	// Create test directories
 	testDirs := []string{
@@ -255,17 +238,18 @@ func TestPreventExceeding99(t *testing.T) {
 		"20.02 Second",
 		"20.03 Third",
 	}
-	createTestDirs(t, tempDir, testDirs)
+	createTestDirs(t, fs, tempDir, testDirs)
 
 	// Try to renumber starting from 98
 	cfg := Config{
-		OldPrefix:   "20",
-		StartFrom:   98,
+		OldPrefix:  "20",
+		StartFrom:  98,
 		Dir:        tempDir,
 		DigitCount: 2,
+		Fs:         fs,
 	}
 
-	err = renameDirectories(cfg)
+	err := renameDirectories(cfg)
 	if err == nil {
 		t.Error("Expected an error when renumbering would exceed xx.99, but got none")
 	} else if !strings.Contains(err.Error(), "would exceed xx.99") {
@@ -278,7 +262,7 @@ func TestPreventExceeding99(t *testing.T) {
 		"20.02 Second",
 		"20.03 Third",
 	}
-	actual := getDirNames(t, tempDir)
+	actual := getDirNames(t, fs, tempDir)
 
 	if len(actual) != len(expected) {
 		t.Errorf("Expected %d directories, got %d", len(expected), len(actual))
@@ -296,12 +280,8 @@ func TestPreventExceeding99(t *testing.T) {
 }
 
 func TestLongerDecimalNumbers(t *testing.T) {
-	// Create temporary directory
-	tempDir, err := os.MkdirTemp("", "johnny-decimal-test-*")
-	if err != nil {
-		t.Fatalf("Failed to create temp dir: %v", err)
-	}
-	defer os.RemoveAll(tempDir)
+	fs := afero.NewMemMapFs()
+	tempDir := "/johnny-decimal-test"
 
 	// Create test directories
 	testDirs := []string{
@@ -309,18 +289,19 @@ func TestLongerDecimalNumbers(t *testing.T) {
 		"20.02 Second",
 		"20.10 Third",
 	}
-	createTestDirs(t, tempDir, testDirs)
+	createTestDirs(t, fs, tempDir, testDirs)
 
 	// Try to renumber with 4 digits
 	cfg := Config{
-		OldPrefix:   "20",
-		NewPrefix:   "90",
-		StartFrom:   1,
+		OldPrefix:  "20",
+		NewPrefix:  "90",
+		StartFrom:  1,
 		Dir:        tempDir,
 		DigitCount: 4,
+		Fs:         fs,
 	}
 
-	err = renameDirectories(cfg)
+	err := renameDirectories(cfg)
 	if err != nil {
 		t.Fatalf("Failed to rename directories: %v", err)
 	}
@@ -331,7 +312,7 @@ func TestLongerDecimalNumbers(t *testing.T) {
 		"90.0002 Second",
 		"90.0003 Third",
 	}
-	actual := getDirNames(t, tempDir)
+	actual := getDirNames(t, fs, tempDir)
 
 	if len(actual) != len(expected) {
 		t.Errorf("Expected %d directories, got %d", len(expected), len(actual))
@@ -349,12 +330,8 @@ func TestLongerDecimalNumbers(t *testing.T) {
 }
 
 func TestLongerDecimalNumbersValidation(t *testing.T) {
-	// Create temporary directory
-	tempDir, err := os.MkdirTemp("", "johnny-decimal-test-*")
-	if err != nil {
-		t.Fatalf("Failed to create temp dir: %v", err)
-	}
-	defer os.RemoveAll(tempDir)
+	fs := afero.NewMemMapFs()
+	tempDir := "/johnny-decimal-test"
 
 	// Create test directories
 	testDirs := []string{
@@ -362,17 +339,18 @@ func TestLongerDecimalNumbersValidation(t *testing.T) {
 		"20.0002 Second",
 		"20.0003 Third",
 	}
-	createTestDirs(t, tempDir, testDirs)
+	createTestDirs(t, fs, tempDir, testDirs)
 
 	// Try to renumber with a number that would exceed the maximum
 	cfg := Config{
-		OldPrefix:   "20",
-		StartFrom:   9998,
+		OldPrefix:  "20",
+		StartFrom:  9998,
 		Dir:        tempDir,
 		DigitCount: 4,
+		Fs:         fs,
 	}
 
-	err = renameDirectories(cfg)
+	err := renameDirectories(cfg)
 	if err == nil {
 		t.Error("Expected an error when renumbering would exceed the maximum, but got none")
 	} else if !strings.Contains(err.Error(), "would exceed xx.9999") {
@@ -385,7 +363,7 @@ func TestLongerDecimalNumbersValidation(t *testing.T) {
 		"20.0002 Second",
 		"20.0003 Third",
 	}
-	actual := getDirNames(t, tempDir)
+	actual := getDirNames(t, fs, tempDir)
 
 	if len(actual) != len(expected) {
 		t.Errorf("Expected %d directories, got %d", len(expected), len(actual))
@@ -403,12 +381,8 @@ func TestLongerDecimalNumbersValidation(t *testing.T) {
 }
 
 func TestDryRun(t *testing.T) {
-	// Create temporary directory
-	tempDir, err := os.MkdirTemp("", "johnny-decimal-test-*")
-	if err != nil {
-		t.Fatalf("Failed to create temp dir: %v", err)
-	}
-	defer os.RemoveAll(tempDir)
+	fs := afero.NewMemMapFs()
+	tempDir := "/johnny-decimal-test"
 
 	// Create test directories
 	testDirs := []string{
@@ -416,18 +390,19 @@ func TestDryRun(t *testing.T) {
 		"10.02 Documents",
 		"10.03 Archive",
 	}
-	createTestDirs(t, tempDir, testDirs)
+	createTestDirs(t, fs, tempDir, testDirs)
 
 	// Run the program with dry-run
 	cfg := Config{
-		OldPrefix:   "10",
-		NewPrefix:   "20",
+		OldPrefix:  "10",
+		NewPrefix:  "20",
 		Dir:        tempDir,
 		DryRun:     true,
 		DigitCount: 2,
+		Fs:         fs,
 	}
 
-	err = renameDirectories(cfg)
+	err := renameDirectories(cfg)
 	if err != nil {
 		t.Fatalf("Failed to rename directories: %v", err)
 	}
@@ -438,7 +413,7 @@ func TestDryRun(t *testing.T) {
 		"10.02 Documents",
 		"10.03 Archive",
 	}
-	actual := getDirNames(t, tempDir)
+	actual := getDirNames(t, fs, tempDir)
 
 	if len(actual) != len(expected) {
 		t.Errorf("Expected %d directories, got %d", len(expected), len(actual))
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/spf13/afero"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+	"golang.org/x/text/unicode/norm"
+)
+
+var whitespaceRe = regexp.MustCompile(`\s+`)
+var windowsIllegalRe = regexp.MustCompile(`[<>:"/\\|?*]`)
+
+// titleCaser title-cases a word the way a human would, unlike the deprecated
+// strings.Title, which mishandles word boundaries after punctuation (e.g.
+// "can't" -> "Can'T"). language.Und applies the language-neutral default
+// rules, since restName may contain words from any language.
+var titleCaser = cases.Title(language.Und)
+
+// MakeName normalizes the human-readable "restName" portion of a Johnny
+// Decimal folder name (the part after "NN.NN "), so names copied from the
+// web - mixed case, accents, trailing spaces, %xx escapes, separators that
+// don't play well across macOS/Windows/Linux - come out consistent.
+//
+// mode selects the case/separator preset: "title" (Title-Case, words joined
+// by sep), "slug" (lower-case, words joined by sep), "kebab" (lower-case,
+// words always joined by "-"), or "none"/"" (case and spacing left alone
+// beyond the cleanup steps below). removeAccents strips combining marks via
+// NFD decomposition before recomposing to NFC, so accented Latin letters
+// lose their diacritics while other scripts round-trip unchanged. maxLen
+// truncates the result to that many runes when positive.
+func MakeName(name string, mode string, sep string, removeAccents bool, maxLen int) string {
+	if decoded, err := url.PathUnescape(name); err == nil {
+		name = decoded
+	}
+
+	if removeAccents {
+		name = stripAccents(name)
+	}
+
+	name = windowsIllegalRe.ReplaceAllString(name, "")
+	name = strings.TrimSpace(whitespaceRe.ReplaceAllString(name, " "))
+
+	switch mode {
+	case "title":
+		name = joinWords(name, sep, titleCaser.String)
+	case "slug":
+		name = joinWords(name, sep, strings.ToLower)
+	case "kebab":
+		name = joinWords(name, "-", strings.ToLower)
+	}
+
+	if maxLen > 0 {
+		runes := []rune(name)
+		if len(runes) > maxLen {
+			name = string(runes[:maxLen])
+		}
+	}
+
+	return name
+}
+
+func joinWords(name string, sep string, wordCase func(string) string) string {
+	words := strings.Fields(name)
+	for i, w := range words {
+		words[i] = wordCase(w)
+	}
+	return strings.Join(words, sep)
+}
+
+// stripAccents decomposes s (NFD), drops combining marks (category Mn), and
+// recomposes (NFC) so unaffected precomposed characters - e.g. Hangul -
+// come back exactly as they went in.
+func stripAccents(s string) string {
+	decomposed := norm.NFD.String(s)
+	var b strings.Builder
+	for _, r := range decomposed {
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return norm.NFC.String(b.String())
+}
+
+// computeNormalizeOnlyPlan walks every "<number> <restName>" folder under
+// cfg.Dir and proposes a rename that rewrites restName via MakeName,
+// leaving the numeric prefix at every level untouched.
+func computeNormalizeOnlyPlan(fs afero.Fs, cfg Config) ([]renameStep, error) {
+	var plan []renameStep
+	err := afero.Walk(fs, cfg.Dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() || path == cfg.Dir {
+			return nil
+		}
+
+		baseName := filepath.Base(path)
+		parts := strings.SplitN(baseName, " ", 2)
+		if len(parts) != 2 {
+			return nil
+		}
+
+		newRest := MakeName(parts[1], cfg.Normalize, cfg.NameSeparator, cfg.RemoveAccents, cfg.MaxNameLen)
+		if newRest == parts[1] {
+			return nil
+		}
+
+		newPath := filepath.Join(filepath.Dir(path), parts[0]+" "+newRest)
+		plan = append(plan, renameStep{From: path, To: newPath})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error walking directory: %v", err)
+	}
+	return plan, nil
+}
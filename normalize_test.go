@@ -0,0 +1,105 @@
+package main
+
+import "testing"
+
+func TestMakeName(t *testing.T) {
+	cases := []struct {
+		name          string
+		in            string
+		mode          string
+		sep           string
+		removeAccents bool
+		maxLen        int
+		want          string
+	}{
+		{
+			name: "collapses whitespace and trims",
+			in:   "  Some   folder  ",
+			mode: "none",
+			want: "Some folder",
+		},
+		{
+			name: "title case with dash separator",
+			in:   "some folder name",
+			mode: "title",
+			sep:  "-",
+			want: "Some-Folder-Name",
+		},
+		{
+			name: "slug lower-case with underscore separator",
+			in:   "Project Alpha",
+			mode: "slug",
+			sep:  "_",
+			want: "project_alpha",
+		},
+		{
+			name: "kebab always uses a dash regardless of sep",
+			in:   "Project Alpha",
+			mode: "kebab",
+			sep:  "_",
+			want: "project-alpha",
+		},
+		{
+			name: "strips windows-illegal characters",
+			in:   `Q4 Report: "Final"?`,
+			mode: "none",
+			want: "Q4 Report Final",
+		},
+		{
+			name: "decodes url-encoded escapes",
+			in:   "New%20Folder",
+			mode: "none",
+			want: "New Folder",
+		},
+		{
+			name: "leaves literal plus signs alone",
+			in:   "C++ Notes",
+			mode: "none",
+			want: "C++ Notes",
+		},
+		{
+			name:          "removes accents from latin script",
+			in:            "Café Déjà Vu",
+			mode:          "none",
+			removeAccents: true,
+			want:          "Cafe Deja Vu",
+		},
+		{
+			name:          "cyrillic passes through unchanged",
+			in:            "Привет мир",
+			mode:          "none",
+			removeAccents: true,
+			want:          "Привет мир",
+		},
+		{
+			name:          "korean passes through unchanged",
+			in:            "안녕하세요",
+			mode:          "none",
+			removeAccents: true,
+			want:          "안녕하세요",
+		},
+		{
+			name:          "devanagari passes through recognizably",
+			in:            "नमस्ते",
+			mode:          "none",
+			removeAccents: false,
+			want:          "नमस्ते",
+		},
+		{
+			name:   "truncates to max length",
+			in:     "A very long folder name",
+			mode:   "none",
+			maxLen: 10,
+			want:   "A very lon",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := MakeName(c.in, c.mode, c.sep, c.removeAccents, c.maxLen)
+			if got != c.want {
+				t.Errorf("MakeName(%q) = %q, want %q", c.in, got, c.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/afero"
+)
+
+// renameStep is one planned rename, computed and validated up front, before
+// any filesystem change is made.
+type renameStep struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// journalEntry is one line of the rollback journal. The first entry is
+// always "plan", recording the whole transaction and the session id used to
+// derive temporary names; "tmp" and "final" entries record each physical
+// rename as it completes, in order, so a crash mid-run can be resumed or a
+// completed run can be undone.
+type journalEntry struct {
+	Op      string       `json:"op"`
+	Session string       `json:"session,omitempty"`
+	Idx     int          `json:"idx,omitempty"`
+	From    string       `json:"from,omitempty"`
+	To      string       `json:"to,omitempty"`
+	Steps   []renameStep `json:"steps,omitempty"`
+}
+
+func newSessionID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generating journal session id: %v", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func tempName(dir, session string, idx int) string {
+	return filepath.Join(dir, fmt.Sprintf(".jdc-tmp-%s-%d", session, idx))
+}
+
+// appendJournal writes one entry to the journal. truncate discards whatever
+// the file already held first, which runTransaction uses on the "plan"
+// entry so each run starts from a clean journal instead of piling onto
+// whatever a previous run (successful or not) left behind.
+func appendJournal(journalFs afero.Fs, journalPath string, entry journalEntry, truncate bool) error {
+	flags := os.O_APPEND | os.O_CREATE | os.O_WRONLY
+	if truncate {
+		flags = os.O_TRUNC | os.O_CREATE | os.O_WRONLY
+	}
+	f, err := journalFs.OpenFile(journalPath, flags, 0644)
+	if err != nil {
+		return fmt.Errorf("opening journal %s: %v", journalPath, err)
+	}
+	defer f.Close()
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("encoding journal entry: %v", err)
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("writing journal %s: %v", journalPath, err)
+	}
+	return f.Sync()
+}
+
+func readJournal(journalFs afero.Fs, journalPath string) ([]journalEntry, error) {
+	f, err := journalFs.Open(journalPath)
+	if err != nil {
+		return nil, fmt.Errorf("opening journal %s: %v", journalPath, err)
+	}
+	defer f.Close()
+
+	var entries []journalEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var entry journalEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return nil, fmt.Errorf("parsing journal %s: %v", journalPath, err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading journal %s: %v", journalPath, err)
+	}
+	return entries, nil
+}
+
+// runTransaction executes a validated rename plan as a two-phase commit:
+// every source is first renamed to a unique temporary name, then every
+// temporary name is renamed to its final target. This makes cyclic renames
+// (A -> B, B -> A) trivial, since both sources are vacated before either
+// final name is claimed. Each physical rename is journaled before the next
+// one starts, so a failure partway through rolls back cleanly.
+//
+// fs is the filesystem being reorganized (which may be a remote backend,
+// e.g. webdav) and journalFs is always a local filesystem: the journal
+// records progress so a crash can be resumed or undone, which only works if
+// it survives independently of whatever fs is doing.
+//
+// fresh controls whether this call starts a brand new journal (truncating
+// whatever an earlier, unrelated run left behind) or chains onto one a prior
+// runTransaction call against the same journalPath already started. Callers
+// that need several dependent runTransaction calls to behave as one unit -
+// e.g. renaming a category directory, then its ID children, where nesting
+// both in a single plan would corrupt the two-phase temp-name bookkeeping -
+// pass fresh=true for the first call and fresh=false for the rest, so a
+// failure or -undo at any point rolls back every call in the chain, not just
+// the one that failed. Standalone callers always pass fresh=true.
+//
+// On success the journal is left in place (scoped to this run, or this
+// chain of runs) so -undo can still reverse it later; on failure, rollback
+// removes it once every step recorded so far - across the whole chain - has
+// been reversed.
+func runTransaction(fs afero.Fs, journalFs afero.Fs, plan []renameStep, journalPath string, fresh bool) (err error) {
+	if len(plan) == 0 {
+		return nil
+	}
+
+	session, err := newSessionID()
+	if err != nil {
+		return err
+	}
+
+	if err = appendJournal(journalFs, journalPath, journalEntry{Op: "plan", Session: session, Steps: plan}, fresh); err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			if rbErr := rollback(fs, journalFs, journalPath); rbErr != nil {
+				err = fmt.Errorf("%v (rollback also failed: %v)", err, rbErr)
+			}
+		}
+	}()
+
+	temps := make([]string, len(plan))
+	for i, step := range plan {
+		temps[i] = tempName(filepath.Dir(step.From), session, i)
+		if err = fs.Rename(step.From, temps[i]); err != nil {
+			return fmt.Errorf("renaming %s to temporary name: %v", step.From, err)
+		}
+		if err = appendJournal(journalFs, journalPath, journalEntry{Op: "tmp", Idx: i, From: step.From, To: temps[i]}, false); err != nil {
+			return err
+		}
+	}
+
+	for i, step := range plan {
+		if err = fs.Rename(temps[i], step.To); err != nil {
+			return fmt.Errorf("renaming temporary name to %s: %v", step.To, err)
+		}
+		if err = appendJournal(journalFs, journalPath, journalEntry{Op: "final", Idx: i, From: temps[i], To: step.To}, false); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// rollback undoes every physical rename recorded in the journal, in reverse
+// order, then removes the journal: once every rename it recorded has been
+// reversed, it no longer describes the tree's state and must not be resumed
+// or undone again.
+func rollback(fs afero.Fs, journalFs afero.Fs, journalPath string) error {
+	entries, err := readJournal(journalFs, journalPath)
+	if err != nil {
+		return err
+	}
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+		if entry.Op == "plan" {
+			continue
+		}
+		if err := fs.Rename(entry.To, entry.From); err != nil {
+			return fmt.Errorf("undoing %s -> %s: %v", entry.From, entry.To, err)
+		}
+	}
+	if rmErr := journalFs.Remove(journalPath); rmErr != nil {
+		fmt.Printf("Warning: could not remove reversed journal %s: %v\n", journalPath, rmErr)
+	}
+	return nil
+}
+
+// resumeTransaction detects a journal left behind by a crashed run and
+// completes whichever steps had not yet reached their final name. A journal
+// may hold more than one "plan" entry when several runTransaction calls were
+// chained (fresh=false) into one logical operation; only the last one
+// describes the run that was actually in progress when it crashed; earlier
+// ones already completed (that's how chaining reached a later call at all),
+// so only the tmp/final entries recorded after it apply.
+func resumeTransaction(fs afero.Fs, journalFs afero.Fs, journalPath string) error {
+	entries, err := readJournal(journalFs, journalPath)
+	if err != nil {
+		return err
+	}
+	headerIdx := -1
+	for i, entry := range entries {
+		if entry.Op == "plan" {
+			headerIdx = i
+		}
+	}
+	if headerIdx == -1 {
+		return fmt.Errorf("%s does not start with a plan entry", journalPath)
+	}
+	header := entries[headerIdx]
+
+	tmpDone := make(map[int]string)
+	finalDone := make(map[int]bool)
+	for _, entry := range entries[headerIdx+1:] {
+		switch entry.Op {
+		case "tmp":
+			tmpDone[entry.Idx] = entry.To
+		case "final":
+			finalDone[entry.Idx] = true
+		}
+	}
+
+	for i, step := range header.Steps {
+		if _, ok := tmpDone[i]; ok {
+			continue
+		}
+		temp := tempName(filepath.Dir(step.From), header.Session, i)
+		fmt.Printf("Resuming: %s -> %s\n", step.From, temp)
+		if err := fs.Rename(step.From, temp); err != nil {
+			return fmt.Errorf("resuming: renaming %s to temporary name: %v", step.From, err)
+		}
+		if err := appendJournal(journalFs, journalPath, journalEntry{Op: "tmp", Idx: i, From: step.From, To: temp}, false); err != nil {
+			return err
+		}
+		tmpDone[i] = temp
+	}
+
+	for i, step := range header.Steps {
+		if finalDone[i] {
+			continue
+		}
+		temp := tmpDone[i]
+		fmt.Printf("Resuming: %s -> %s\n", temp, step.To)
+		if err := fs.Rename(temp, step.To); err != nil {
+			return fmt.Errorf("resuming: renaming temporary name to %s: %v", step.To, err)
+		}
+		if err := appendJournal(journalFs, journalPath, journalEntry{Op: "final", Idx: i, From: temp, To: step.To}, false); err != nil {
+			return err
+		}
+	}
+
+	// The journal now reflects a fully completed run, same as a successful
+	// runTransaction; it's left in place so -undo can still reverse it.
+	return nil
+}
+
+// undoTransaction reverses every physical rename recorded in a completed
+// journal.
+func undoTransaction(fs afero.Fs, journalFs afero.Fs, journalPath string) error {
+	return rollback(fs, journalFs, journalPath)
+}
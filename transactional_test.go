@@ -0,0 +1,188 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestRenameDirectoriesHandlesCyclicSwap(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	tempDir := "/johnny-decimal-test"
+
+	createTestDirs(t, fs, tempDir, []string{
+		"10.02 Second",
+		"10.03 Third",
+	})
+
+	// Swap 10.02 <-> 10.03 by renumbering in reverse order: this produces a
+	// plan with colliding intermediate targets that only the temp-name
+	// phase can resolve.
+	cfg := Config{
+		OldPrefix:  "10",
+		NewPrefix:  "10",
+		Dir:        tempDir,
+		DigitCount: 2,
+		Fs:         fs,
+	}
+	cfg.JournalPath = tempDir + "/.jdc-journal.jsonl"
+
+	// Hand-build the swap plan directly, since computeRenamePlan always
+	// preserves relative order.
+	plan := []renameStep{
+		{From: tempDir + "/10.02 Second", To: tempDir + "/10.03 Second"},
+		{From: tempDir + "/10.03 Third", To: tempDir + "/10.02 Third"},
+	}
+
+	if err := runTransaction(fs, fs, plan, cfg.JournalPath, true); err != nil {
+		t.Fatalf("runTransaction failed: %v", err)
+	}
+
+	expected := []string{"10.02 Third", "10.03 Second"}
+	actual := getDirNames(t, fs, tempDir)
+	if len(actual) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, actual)
+	}
+	for i := range expected {
+		if actual[i] != expected[i] {
+			t.Errorf("expected %s, got %s", expected[i], actual[i])
+		}
+	}
+}
+
+func TestUndoTransactionReversesCompletedRun(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	tempDir := "/johnny-decimal-test"
+	journalPath := tempDir + "/.jdc-journal.jsonl"
+
+	createTestDirs(t, fs, tempDir, []string{"10.01 Projects"})
+
+	plan := []renameStep{
+		{From: tempDir + "/10.01 Projects", To: tempDir + "/20.01 Projects"},
+	}
+	if err := runTransaction(fs, fs, plan, journalPath, true); err != nil {
+		t.Fatalf("runTransaction failed: %v", err)
+	}
+
+	if err := undoTransaction(fs, fs, journalPath); err != nil {
+		t.Fatalf("undoTransaction failed: %v", err)
+	}
+
+	actual := getDirNames(t, fs, tempDir)
+	if len(actual) != 1 || actual[0] != "10.01 Projects" {
+		t.Fatalf("expected undo to restore 10.01 Projects, got %v", actual)
+	}
+}
+
+func TestChainedRunRollsBackBothCallsOnLaterFailure(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	tempDir := "/johnny-decimal-test"
+	journalPath := tempDir + "/.jdc-journal.jsonl"
+
+	createTestDirs(t, fs, tempDir, []string{"10.01 Projects", "10.02 Archive"})
+
+	// First call starts the journal fresh and succeeds.
+	firstPlan := []renameStep{
+		{From: tempDir + "/10.01 Projects", To: tempDir + "/20.01 Projects"},
+	}
+	if err := runTransaction(fs, fs, firstPlan, journalPath, true); err != nil {
+		t.Fatalf("first runTransaction failed: %v", err)
+	}
+
+	// Second call chains onto the same journal (fresh=false) and fails; its
+	// rollback must also undo the first call's rename, since together they
+	// represent one logical operation.
+	secondPlan := []renameStep{
+		{From: tempDir + "/10.02 Archive", To: tempDir + "/20.02 Archive"},
+		{From: tempDir + "/does-not-exist", To: tempDir + "/20.03 Nope"},
+	}
+	if err := runTransaction(fs, fs, secondPlan, journalPath, false); err == nil {
+		t.Fatal("expected the second run to fail")
+	}
+
+	actual := getDirNames(t, fs, tempDir)
+	expected := []string{"10.01 Projects", "10.02 Archive"}
+	if len(actual) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, actual)
+	}
+	for i := range expected {
+		if actual[i] != expected[i] {
+			t.Errorf("expected %s, got %s", expected[i], actual[i])
+		}
+	}
+}
+
+func TestUndoAfterChainedRunReversesBothCalls(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	tempDir := "/johnny-decimal-test"
+	journalPath := tempDir + "/.jdc-journal.jsonl"
+
+	createTestDirs(t, fs, tempDir, []string{"10.01 Projects", "10.02 Archive"})
+
+	firstPlan := []renameStep{
+		{From: tempDir + "/10.01 Projects", To: tempDir + "/20.01 Projects"},
+	}
+	if err := runTransaction(fs, fs, firstPlan, journalPath, true); err != nil {
+		t.Fatalf("first runTransaction failed: %v", err)
+	}
+
+	secondPlan := []renameStep{
+		{From: tempDir + "/10.02 Archive", To: tempDir + "/20.02 Archive"},
+	}
+	if err := runTransaction(fs, fs, secondPlan, journalPath, false); err != nil {
+		t.Fatalf("second runTransaction failed: %v", err)
+	}
+
+	if err := undoTransaction(fs, fs, journalPath); err != nil {
+		t.Fatalf("undoTransaction failed: %v", err)
+	}
+
+	actual := getDirNames(t, fs, tempDir)
+	expected := []string{"10.01 Projects", "10.02 Archive"}
+	if len(actual) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, actual)
+	}
+	for i := range expected {
+		if actual[i] != expected[i] {
+			t.Errorf("expected %s, got %s", expected[i], actual[i])
+		}
+	}
+}
+
+func TestSecondRunDoesNotRollBackFirstRunOnSharedJournal(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	tempDir := "/johnny-decimal-test"
+	journalPath := tempDir + "/.jdc-journal.jsonl"
+
+	createTestDirs(t, fs, tempDir, []string{"10.01 Projects", "10.02 Archive"})
+
+	// First run succeeds and leaves its own record in the journal.
+	firstPlan := []renameStep{
+		{From: tempDir + "/10.01 Projects", To: tempDir + "/20.01 Projects"},
+	}
+	if err := runTransaction(fs, fs, firstPlan, journalPath, true); err != nil {
+		t.Fatalf("first runTransaction failed: %v", err)
+	}
+
+	// Second run, over a plan whose source doesn't exist, fails and rolls
+	// back. If the journal still held the first run's plan, rollback would
+	// try to reverse it too and fail on a path that no longer exists.
+	secondPlan := []renameStep{
+		{From: tempDir + "/10.02 Archive", To: tempDir + "/20.02 Archive"},
+		{From: tempDir + "/does-not-exist", To: tempDir + "/20.03 Nope"},
+	}
+	if err := runTransaction(fs, fs, secondPlan, journalPath, true); err == nil {
+		t.Fatal("expected the second run to fail")
+	}
+
+	actual := getDirNames(t, fs, tempDir)
+	expected := []string{"10.02 Archive", "20.01 Projects"}
+	if len(actual) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, actual)
+	}
+	for i := range expected {
+		if actual[i] != expected[i] {
+			t.Errorf("expected %s, got %s", expected[i], actual[i])
+		}
+	}
+}
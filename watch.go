@@ -0,0 +1,193 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/afero"
+)
+
+// createDebounce is how long we let a newly created directory settle (an
+// editor or file manager may still be populating it) before auto-numbering
+// it.
+const createDebounce = 500 * time.Millisecond
+
+// decimalPrefixRe matches an already-numbered ID folder like "11.01 Chase".
+var decimalPrefixRe = regexp.MustCompile(`^\d+\.\d+ .+`)
+
+// watchConfig configures watch mode.
+type watchConfig struct {
+	Dir         string
+	DigitCount  int
+	IgnoreGlobs []string
+}
+
+func matchesIgnoreGlob(name string, globs []string) bool {
+	for _, g := range globs {
+		if ok, _ := filepath.Match(g, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func hasValidPrefix(name string) bool {
+	return decimalPrefixRe.MatchString(name)
+}
+
+// watchMode watches cfg.Dir recursively and auto-numbers any newly created
+// directory that doesn't already have a valid "NN.NN Name" prefix.
+//
+// inotify (the Linux backend fsnotify wraps) does not watch recursively, so
+// we walk the tree up front to add a watch per directory, then add a watch
+// for every directory fsnotify reports as newly created.
+func watchMode(cfg watchConfig) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("creating watcher: %v", err)
+	}
+	defer watcher.Close()
+
+	if err := addWatchRecursive(watcher, cfg.Dir); err != nil {
+		return err
+	}
+
+	var mu sync.Mutex
+	pending := map[string]*time.Timer{}
+
+	fmt.Printf("Watching %s for new folders...\n", cfg.Dir)
+
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+
+			base := filepath.Base(event.Name)
+			if matchesIgnoreGlob(base, cfg.IgnoreGlobs) {
+				continue
+			}
+
+			switch {
+			case event.Op&fsnotify.Create == fsnotify.Create:
+				info, err := os.Stat(event.Name)
+				if err != nil || !info.IsDir() {
+					continue
+				}
+				if err := watcher.Add(event.Name); err != nil {
+					fmt.Printf("Error watching %s: %v\n", event.Name, err)
+				}
+				if hasValidPrefix(base) {
+					continue
+				}
+
+				name := event.Name
+				mu.Lock()
+				if t, exists := pending[name]; exists {
+					t.Stop()
+				}
+				pending[name] = time.AfterFunc(createDebounce, func() {
+					mu.Lock()
+					delete(pending, name)
+					mu.Unlock()
+					if err := assignNextDecimal(cfg, name); err != nil {
+						fmt.Printf("Error numbering %s: %v\n", name, err)
+					}
+				})
+				mu.Unlock()
+
+			case event.Op&(fsnotify.Remove|fsnotify.Rename) != 0:
+				mu.Lock()
+				if t, exists := pending[event.Name]; exists {
+					t.Stop()
+					delete(pending, event.Name)
+				}
+				mu.Unlock()
+			}
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			fmt.Printf("Watcher error: %v\n", err)
+		}
+	}
+}
+
+func addWatchRecursive(watcher *fsnotify.Watcher, root string) error {
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return watcher.Add(path)
+		}
+		return nil
+	})
+}
+
+// categoryPrefix extracts the decimal prefix (e.g. "11") from a category
+// directory named "11 Banking". It reports false if dir doesn't look like a
+// Johnny Decimal category, in which case we can't safely guess a prefix.
+func categoryPrefix(dir string) (string, bool) {
+	parts := strings.SplitN(filepath.Base(dir), " ", 2)
+	if len(parts) != 2 {
+		return "", false
+	}
+	if _, err := strconv.Atoi(parts[0]); err != nil {
+		return "", false
+	}
+	return parts[0], true
+}
+
+// assignNextDecimal renumbers a newly created directory to the next free
+// decimal in its containing category, then renames it through the same
+// transactional path the rest of the tool uses.
+func assignNextDecimal(cfg watchConfig, newDirPath string) error {
+	parent := filepath.Dir(newDirPath)
+	prefix, ok := categoryPrefix(parent)
+	if !ok {
+		return nil
+	}
+
+	entries, err := os.ReadDir(parent)
+	if err != nil {
+		return fmt.Errorf("reading %s: %v", parent, err)
+	}
+
+	maxID := 0
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		parts := strings.SplitN(entry.Name(), " ", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		numberParts := strings.Split(parts[0], ".")
+		if len(numberParts) != 2 || numberParts[0] != prefix {
+			continue
+		}
+		if id, err := strconv.Atoi(numberParts[1]); err == nil && id > maxID {
+			maxID = id
+		}
+	}
+
+	format := fmt.Sprintf("%%s.%%0%dd %%s", cfg.DigitCount)
+	newName := fmt.Sprintf(format, prefix, maxID+1, filepath.Base(newDirPath))
+	newPath := filepath.Join(parent, newName)
+
+	fmt.Printf("Auto-numbering: %s -> %s\n", newDirPath, newPath)
+
+	journalPath := filepath.Join(cfg.Dir, ".jdc-journal.jsonl")
+	osFs := afero.NewOsFs()
+	return runTransaction(osFs, osFs, []renameStep{{From: newDirPath, To: newPath}}, journalPath, true)
+}
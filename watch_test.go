@@ -0,0 +1,39 @@
+package main
+
+import "testing"
+
+func TestHasValidPrefix(t *testing.T) {
+	cases := map[string]bool{
+		"11.01 Chase": true,
+		"New folder":  false,
+		"11 Banking":  false,
+		"11.01Chase":  false,
+	}
+	for name, want := range cases {
+		if got := hasValidPrefix(name); got != want {
+			t.Errorf("hasValidPrefix(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestCategoryPrefix(t *testing.T) {
+	if prefix, ok := categoryPrefix("/root/11 Banking"); !ok || prefix != "11" {
+		t.Errorf("expected prefix 11, got %q (ok=%v)", prefix, ok)
+	}
+	if _, ok := categoryPrefix("/root/Misc"); ok {
+		t.Error("expected no prefix for a non-category directory")
+	}
+}
+
+func TestMatchesIgnoreGlob(t *testing.T) {
+	globs := []string{".*", "~*"}
+	if !matchesIgnoreGlob(".DS_Store", globs) {
+		t.Error("expected .DS_Store to be ignored")
+	}
+	if !matchesIgnoreGlob("~lock.tmp", globs) {
+		t.Error("expected ~lock.tmp to be ignored")
+	}
+	if matchesIgnoreGlob("11.01 Chase", globs) {
+		t.Error("did not expect 11.01 Chase to be ignored")
+	}
+}
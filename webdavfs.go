@@ -0,0 +1,253 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+// webdavFs is a minimal afero.Fs backed by a remote WebDAV share (e.g. a
+// Nextcloud folder), so a Johnny Decimal tree that lives there can be
+// reorganized without mounting it locally. It only implements the subset of
+// the afero.Fs contract that renameDirectories and afero.Walk exercise:
+// Stat, Open (for directory listing), and Rename. This tool never creates,
+// writes to, or deletes files on the remote share, so the rest of the
+// interface returns an error.
+//
+// This is a hand-rolled client (PROPFIND/MOVE/MKCOL/DELETE over net/http)
+// rather than golang.org/x/net/webdav: that package only implements a WebDAV
+// *server* (http.Handler), with no client-side API to speak the protocol
+// against someone else's share.
+type webdavFs struct {
+	base   *url.URL
+	client *http.Client
+}
+
+func newWebdavFs(base *url.URL) (afero.Fs, error) {
+	if base.Scheme != "http" && base.Scheme != "https" {
+		return nil, fmt.Errorf("webdav backend requires an http(s) URL, got %q", base.String())
+	}
+	return &webdavFs{base: base, client: http.DefaultClient}, nil
+}
+
+func (fs *webdavFs) resolve(name string) string {
+	u := *fs.base
+	u.Path = path.Join(u.Path, name)
+	return u.String()
+}
+
+type davMultistatus struct {
+	Responses []davResponse `xml:"response"`
+}
+
+type davResponse struct {
+	Href       string   `xml:"href"`
+	Collection []string `xml:"propstat>prop>resourcetype>collection"`
+	LastMod    string   `xml:"propstat>prop>getlastmodified"`
+}
+
+func (fs *webdavFs) propfind(name string, depth string) (*davMultistatus, error) {
+	body := `<?xml version="1.0" encoding="utf-8"?>
+<D:propfind xmlns:D="DAV:"><D:prop><D:resourcetype/><D:getlastmodified/></D:prop></D:propfind>`
+	req, err := http.NewRequest("PROPFIND", fs.resolve(name), bytes.NewBufferString(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Depth", depth)
+	req.Header.Set("Content-Type", "application/xml")
+	resp, err := fs.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 207 {
+		return nil, fmt.Errorf("webdav PROPFIND %s: %s", name, resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	var ms davMultistatus
+	if err := xml.Unmarshal(data, &ms); err != nil {
+		return nil, fmt.Errorf("parsing webdav response for %s: %v", name, err)
+	}
+	return &ms, nil
+}
+
+func (fs *webdavFs) Rename(oldname, newname string) error {
+	req, err := http.NewRequest("MOVE", fs.resolve(oldname), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Destination", fs.resolve(newname))
+	req.Header.Set("Overwrite", "F")
+	resp, err := fs.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webdav MOVE %s -> %s: %s", oldname, newname, resp.Status)
+	}
+	return nil
+}
+
+func (fs *webdavFs) Stat(name string) (os.FileInfo, error) {
+	ms, err := fs.propfind(name, "0")
+	if err != nil {
+		return nil, err
+	}
+	if len(ms.Responses) == 0 {
+		return nil, fmt.Errorf("webdav: %s not found", name)
+	}
+	return davFileInfo(name, ms.Responses[0]), nil
+}
+
+func (fs *webdavFs) Open(name string) (afero.File, error) {
+	ms, err := fs.propfind(name, "1")
+	if err != nil {
+		return nil, err
+	}
+	if len(ms.Responses) == 0 {
+		return nil, fmt.Errorf("webdav: %s not found", name)
+	}
+	self := davFileInfo(name, ms.Responses[0])
+	var children []os.FileInfo
+	for _, r := range ms.Responses[1:] {
+		childName := path.Join(name, path.Base(strings.TrimSuffix(r.Href, "/")))
+		children = append(children, davFileInfo(childName, r))
+	}
+	return &webdavFile{name: name, info: self, children: children}, nil
+}
+
+func davFileInfo(name string, r davResponse) os.FileInfo {
+	modTime, _ := time.Parse(http.TimeFormat, r.LastMod)
+	return &webdavFileInfo{
+		name:  path.Base(name),
+		isDir: len(r.Collection) > 0,
+		mod:   modTime,
+	}
+}
+
+type webdavFileInfo struct {
+	name  string
+	isDir bool
+	mod   time.Time
+}
+
+func (fi *webdavFileInfo) Name() string { return fi.name }
+func (fi *webdavFileInfo) Size() int64  { return 0 }
+func (fi *webdavFileInfo) Mode() os.FileMode {
+	if fi.isDir {
+		return os.ModeDir | 0755
+	}
+	return 0644
+}
+func (fi *webdavFileInfo) ModTime() time.Time { return fi.mod }
+func (fi *webdavFileInfo) IsDir() bool        { return fi.isDir }
+func (fi *webdavFileInfo) Sys() interface{}   { return nil }
+
+// webdavFile only supports the read-side operations afero.Walk needs
+// (Readdir/Readdirnames and Stat); anything else is unsupported.
+type webdavFile struct {
+	name     string
+	info     os.FileInfo
+	children []os.FileInfo
+}
+
+func (f *webdavFile) Close() error { return nil }
+func (f *webdavFile) Read(p []byte) (int, error) {
+	return 0, fmt.Errorf("webdav: reading file contents is not supported")
+}
+func (f *webdavFile) ReadAt(p []byte, off int64) (int, error) {
+	return 0, fmt.Errorf("webdav: reading file contents is not supported")
+}
+func (f *webdavFile) Seek(offset int64, whence int) (int64, error) { return 0, nil }
+func (f *webdavFile) Write(p []byte) (int, error) {
+	return 0, fmt.Errorf("webdav: writing is not supported by this tool")
+}
+func (f *webdavFile) WriteAt(p []byte, off int64) (int, error) {
+	return 0, fmt.Errorf("webdav: writing is not supported by this tool")
+}
+func (f *webdavFile) Name() string { return f.name }
+func (f *webdavFile) Readdir(count int) ([]os.FileInfo, error) {
+	return f.children, nil
+}
+func (f *webdavFile) Readdirnames(n int) ([]string, error) {
+	names := make([]string, len(f.children))
+	for i, c := range f.children {
+		names[i] = c.Name()
+	}
+	return names, nil
+}
+func (f *webdavFile) Stat() (os.FileInfo, error) { return f.info, nil }
+func (f *webdavFile) Sync() error                { return nil }
+func (f *webdavFile) Truncate(size int64) error {
+	return fmt.Errorf("webdav: truncate is not supported")
+}
+func (f *webdavFile) WriteString(s string) (int, error) {
+	return 0, fmt.Errorf("webdav: writing is not supported by this tool")
+}
+
+func (fs *webdavFs) Create(name string) (afero.File, error) {
+	return nil, fmt.Errorf("webdav: creating files is not supported by this tool")
+}
+func (fs *webdavFs) Mkdir(name string, perm os.FileMode) error {
+	req, err := http.NewRequest("MKCOL", fs.resolve(name), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := fs.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webdav MKCOL %s: %s", name, resp.Status)
+	}
+	return nil
+}
+func (fs *webdavFs) MkdirAll(path string, perm os.FileMode) error {
+	return fmt.Errorf("webdav: MkdirAll is not supported by this tool")
+}
+func (fs *webdavFs) OpenFile(name string, flag int, perm os.FileMode) (afero.File, error) {
+	if flag&(os.O_WRONLY|os.O_RDWR|os.O_CREATE|os.O_APPEND|os.O_TRUNC) != 0 {
+		return nil, fmt.Errorf("webdav: opening %s for writing is not supported by this tool", name)
+	}
+	return fs.Open(name)
+}
+func (fs *webdavFs) Remove(name string) error {
+	req, err := http.NewRequest("DELETE", fs.resolve(name), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := fs.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webdav DELETE %s: %s", name, resp.Status)
+	}
+	return nil
+}
+func (fs *webdavFs) RemoveAll(path string) error { return fs.Remove(path) }
+func (fs *webdavFs) Name() string                { return "webdavFs" }
+func (fs *webdavFs) Chmod(name string, mode os.FileMode) error {
+	return fmt.Errorf("webdav: chmod is not supported")
+}
+func (fs *webdavFs) Chown(name string, uid, gid int) error {
+	return fmt.Errorf("webdav: chown is not supported")
+}
+func (fs *webdavFs) Chtimes(name string, atime, mtime time.Time) error {
+	return fmt.Errorf("webdav: chtimes is not supported")
+}